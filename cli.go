@@ -0,0 +1,514 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"ts-merge-go/engine"
+)
+
+// 命令行退出码
+const (
+	exitOK       = 0   // 处理成功
+	exitFailure  = 1   // 处理失败
+	exitUsage    = 2   // 参数错误
+	exitCanceled = 130 // 被 Ctrl+C 取消
+)
+
+// 命令行子命令
+type cliCommand struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) (interface{}, error)
+}
+
+var cliCommands = []cliCommand{
+	{"merge", "合并多个文件: merge -o 输出.txt [-dedup] 文件1.txt 文件2.txt ...", runMergeCommand},
+	{"split", "拆分文件: split -i 输入.txt -parts 3 [-mode roundrobin|shuffle|stratified|hash [-seed N] [-by country|prefix|carrier -digits 3] [-salt 盐]]|-lines 50000|-size 5MB|-weights 50/30/20 [-outdir 输出目录] [-dedup]", runSplitCommand},
+	{"filter", "按前缀过滤: filter -i 输入.txt -o 输出.txt -prefix 13,14 [-prefix 15] [-prefix-file 前缀.txt] [-exclude] [-dropped] [-type all|mobile|split]", runFilterCommand},
+	{"compare", "比较两个文件: compare -a 文件1.txt -b 文件2.txt -outdir 输出目录", runCompareCommand},
+	{"country-split", "按国家区号拆分: country-split -i 输入.txt -outdir 输出目录 [-by country|carrier|location] [-type all|mobile|split] [-dedup] [-regions] [-city] [-parts N|-lines M]", runCountrySplitCommand},
+	{"number-add", "号码增加: number-add -i 输入.txt -o 输出.txt -position 0 [-digit 9] [-remove-empty]", runNumberAddCommand},
+}
+
+// 命令行输出的处理摘要，固定以一行 JSON 写到标准输出
+type cliSummary struct {
+	Command string      `json:"command"`
+	OK      bool        `json:"ok"`
+	Error   string      `json:"error,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
+}
+
+// 参数错误，退出码与处理失败区分开
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+// 运行命令行模式，返回进程退出码
+func runCLI(args []string) int {
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printCLIUsage(os.Stdout)
+		return exitOK
+	}
+
+	for _, cmd := range cliCommands {
+		if cmd.name != name {
+			continue
+		}
+
+		// Ctrl+C 取消处理，未完成的输出文件会被删除
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		result, err := cmd.run(ctx, args[1:])
+		stop()
+		summary := cliSummary{Command: name, OK: err == nil}
+		code := exitOK
+		if err == nil {
+			summary.Result = result
+		} else {
+			summary.Error = err.Error()
+			code = exitFailure
+			var ue usageError
+			if errors.As(err, &ue) {
+				code = exitUsage
+			} else if err == engine.ErrCanceled {
+				code = exitCanceled
+			}
+		}
+
+		json.NewEncoder(os.Stdout).Encode(summary)
+		return code
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+	printCLIUsage(os.Stderr)
+	return exitUsage
+}
+
+func printCLIUsage(w io.Writer) {
+	fmt.Fprintf(w, "TS-Merge v%s 命令行模式\n\n用法: ts-merge <命令> [参数]\n\n", version)
+	for _, cmd := range cliCommands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w, "\n不带参数启动时打开图形界面。处理结果以 JSON 输出到标准输出，进度输出到标准错误。")
+}
+
+// 创建子命令的参数解析器，错误信息输出到标准错误
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// 解析参数并检查必填项
+func parseFlags(fs *flag.FlagSet, args []string, required ...string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	for _, name := range required {
+		if fs.Lookup(name).Value.String() == "" {
+			return usageErrorf("缺少参数 -%s", name)
+		}
+	}
+	return nil
+}
+
+// 可重复指定、支持逗号分隔的字符串参数
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*s = append(*s, item)
+		}
+	}
+	return nil
+}
+
+// 磁盘去重相关参数
+type spillFlags struct {
+	memMB   *int64
+	tempDir *string
+}
+
+func addSpillFlags(fs *flag.FlagSet) spillFlags {
+	return spillFlags{
+		memMB:   fs.Int64("mem", 0, "内存预算（MB），超出时改用磁盘临时文件；0 为默认 1024，-1 始终使用内存"),
+		tempDir: fs.String("tmpdir", "", "磁盘临时文件目录"),
+	}
+}
+
+// 内存预算（字节），0 和负数原样传给引擎
+func (f spillFlags) budget() int64 {
+	if *f.memMB <= 0 {
+		return *f.memMB
+	}
+	return *f.memMB << 20
+}
+
+// 号码规范化相关参数
+type normalizeFlags struct {
+	enabled *bool
+	format  *string
+}
+
+func addNormalizeFlags(fs *flag.FlagSet) normalizeFlags {
+	return normalizeFlags{
+		enabled: fs.Bool("normalize", false, "规范化号码：去除分隔符和不可见字符、统一 +/00 国际前缀、全角转半角"),
+		format:  fs.String("format", "original", "输出格式：original（保持原样）、e164、digits"),
+	}
+}
+
+func (f normalizeFlags) options() (engine.NormalizeOptions, error) {
+	format, err := engine.ParseOutputFormat(*f.format)
+	if err != nil {
+		return engine.NormalizeOptions{}, usageError{err.Error()}
+	}
+	var opts engine.NormalizeOptions
+	if *f.enabled {
+		opts = engine.DefaultNormalizeOptions()
+	}
+	opts.Format = format
+	return opts, nil
+}
+
+// 号码类型处理方式参数
+func addTypeFlag(fs *flag.FlagSet) *string {
+	return fs.String("type", "all", "号码类型：all（不区分）、mobile（只保留手机号，无法判断类型的保留）、split（每种类型分别输出）")
+}
+
+func parseTypeFlag(name *string) (engine.TypeMode, error) {
+	types, err := engine.ParseTypeMode(*name)
+	if err != nil {
+		return types, usageError{err.Error()}
+	}
+	return types, nil
+}
+
+// 号码校验参数
+func addRejectsFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("rejects", false, "按各国号码长度校验每一行，不合格的行写入 _rejects.txt（文件名:行号、原因），不写入输出")
+}
+
+// 将进度状态输出到标准错误，进度在同一行内刷新
+type cliReporter struct {
+	inProgress bool // 当前行是否为未换行的进度
+}
+
+func (c *cliReporter) Progress(stats engine.Stats) {
+	fmt.Fprintf(os.Stderr, "\r%-60s", stats)
+	c.inProgress = stats.Fraction < 1
+	if !c.inProgress {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+func (c *cliReporter) Status(text string) {
+	if c.inProgress {
+		fmt.Fprintln(os.Stderr)
+		c.inProgress = false
+	}
+	fmt.Fprintln(os.Stderr, text)
+}
+
+func runMergeCommand(ctx context.Context, args []string) (interface{}, error) {
+	fs := newFlagSet("merge")
+	output := fs.String("o", "", "输出文件")
+	dedup := fs.Bool("dedup", false, "去除重复行")
+	rejects := addRejectsFlag(fs)
+	norm := addNormalizeFlags(fs)
+	spill := addSpillFlags(fs)
+	if err := parseFlags(fs, args, "o"); err != nil {
+		return nil, err
+	}
+	if fs.NArg() == 0 {
+		return nil, usageErrorf("请指定要合并的文件")
+	}
+	normalize, err := norm.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.Merge(ctx, engine.MergeOptions{
+		Inputs:       fs.Args(),
+		Output:       *output,
+		Dedup:        *dedup,
+		Normalize:    normalize,
+		Rejects:      *rejects,
+		MemoryBudget: spill.budget(),
+		TempDir:      *spill.tempDir,
+	}, &cliReporter{})
+}
+
+func runSplitCommand(ctx context.Context, args []string) (interface{}, error) {
+	fs := newFlagSet("split")
+	input := fs.String("i", "", "要拆分的文件")
+	parts := fs.Int("parts", 0, "按份数平均拆分")
+	lines := fs.Int("lines", 0, "按每份行数拆分")
+	size := fs.String("size", "", "按每份大小拆分，如 5MB、500KB")
+	weights := fs.String("weights", "", "按比例分配到各份，如 50/30/20")
+	modeName := fs.String("mode", "", "按份数拆分时的分配方式: roundrobin（轮流）、shuffle（随机）、stratified（按分组均分）、hash（按号码哈希分桶），默认平均连续拆分")
+	seed := fs.Int64("seed", 0, "随机分配的种子，相同种子得到相同结果，默认随机生成")
+	by := fs.String("by", "", "按分组均分时的分组依据: country（国家区号，默认）、prefix（号码前缀）、carrier（大陆运营商）")
+	digits := fs.Int("digits", 3, "按号码前缀分组时取前几位")
+	salt := fs.String("salt", "", "哈希分桶的盐，份数和盐不变时同一号码总在同一份")
+	outputDir := fs.String("outdir", "", "分片输出目录，默认写在源文件旁边")
+	dedup := fs.Bool("dedup", false, "去除重复行")
+	rejects := addRejectsFlag(fs)
+	norm := addNormalizeFlags(fs)
+	spill := addSpillFlags(fs)
+	if err := parseFlags(fs, args, "i"); err != nil {
+		return nil, err
+	}
+	modes := 0
+	for _, set := range []bool{*parts != 0, *lines != 0, *size != "", *weights != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return nil, usageErrorf("请指定 -parts、-lines、-size、-weights 其中一个")
+	}
+	mode := engine.SplitByParts
+	var maxBytes int64
+	var weightList []int
+	var err error
+	switch {
+	case *lines != 0:
+		mode = engine.SplitByLines
+	case *size != "":
+		mode = engine.SplitBySize
+		if maxBytes, err = engine.ParseByteSize(*size); err != nil {
+			return nil, usageError{err.Error()}
+		}
+	case *weights != "":
+		mode = engine.SplitWeighted
+		if weightList, err = engine.ParseWeights(*weights); err != nil {
+			return nil, usageError{err.Error()}
+		}
+	}
+	if *modeName != "" {
+		m, err := engine.ParseSplitMode(*modeName)
+		if err != nil || mode != engine.SplitByParts || m != engine.SplitRoundRobin && m != engine.SplitShuffle && m != engine.SplitStratified && m != engine.SplitHash {
+			return nil, usageErrorf("-mode 只能是 roundrobin、shuffle、stratified 或 hash，并与 -parts 一起使用")
+		}
+		mode = m
+	}
+	if *seed != 0 && mode != engine.SplitShuffle {
+		return nil, usageErrorf("-seed 只能与 -mode shuffle 一起使用")
+	}
+	if *salt != "" && mode != engine.SplitHash {
+		return nil, usageErrorf("-salt 只能与 -mode hash 一起使用")
+	}
+	if *by != "" && mode != engine.SplitStratified {
+		return nil, usageErrorf("-by 只能与 -mode stratified 一起使用")
+	}
+	stratifyBy, err := engine.ParseStratifyKey(*by)
+	if err != nil {
+		return nil, usageError{err.Error()}
+	}
+	normalize, err := norm.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.Split(ctx, engine.SplitOptions{
+		Input:        *input,
+		OutputDir:    *outputDir,
+		Dedup:        *dedup,
+		Mode:         mode,
+		Parts:        *parts,
+		LinesPerPart: *lines,
+		MaxBytes:     maxBytes,
+		Weights:      weightList,
+		Seed:         *seed,
+		Salt:         *salt,
+		StratifyBy:   stratifyBy,
+		PrefixDigits: *digits,
+		Normalize:    normalize,
+		Rejects:      *rejects,
+		MemoryBudget: spill.budget(),
+		TempDir:      *spill.tempDir,
+	}, &cliReporter{})
+}
+
+func runFilterCommand(ctx context.Context, args []string) (interface{}, error) {
+	fs := newFlagSet("filter")
+	input := fs.String("i", "", "要过滤的文件")
+	output := fs.String("o", "", "输出文件")
+	var prefixes stringList
+	fs.Var(&prefixes, "prefix", "保留的号码前缀，可重复或用逗号分隔")
+	prefixFile := fs.String("prefix-file", "", "从文件读取前缀列表，每行一个（可与 -prefix 同时使用）")
+	exclude := fs.Bool("exclude", false, "排除模式：丢弃以这些前缀开头的行，保留其余行")
+	writeDropped := fs.Bool("dropped", false, "同时把未保留的行写入 输出_dropped.txt")
+	typeFlag := addTypeFlag(fs)
+	rejects := addRejectsFlag(fs)
+	norm := addNormalizeFlags(fs)
+	if err := parseFlags(fs, args, "i", "o"); err != nil {
+		return nil, err
+	}
+	types, err := parseTypeFlag(typeFlag)
+	if err != nil {
+		return nil, err
+	}
+	if *prefixFile != "" {
+		loaded, err := engine.LoadPrefixFile(*prefixFile)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, loaded...)
+	}
+	if len(prefixes) == 0 && (types == engine.TypeAll || *exclude) {
+		return nil, usageErrorf("请至少输入一个号码前缀")
+	}
+	normalize, err := norm.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.Filter(ctx, engine.FilterOptions{
+		Input:        *input,
+		Output:       *output,
+		Prefixes:     prefixes,
+		Exclude:      *exclude,
+		WriteDropped: *writeDropped,
+		Normalize:    normalize,
+		Types:        types,
+		Rejects:      *rejects,
+	}, &cliReporter{})
+}
+
+func runCompareCommand(ctx context.Context, args []string) (interface{}, error) {
+	fs := newFlagSet("compare")
+	file1 := fs.String("a", "", "文件1")
+	file2 := fs.String("b", "", "文件2")
+	outputDir := fs.String("outdir", "", "输出目录")
+	rejects := addRejectsFlag(fs)
+	norm := addNormalizeFlags(fs)
+	spill := addSpillFlags(fs)
+	if err := parseFlags(fs, args, "a", "b", "outdir"); err != nil {
+		return nil, err
+	}
+	normalize, err := norm.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.Compare(ctx, engine.CompareOptions{
+		File1:        *file1,
+		File2:        *file2,
+		OutputDir:    *outputDir,
+		Normalize:    normalize,
+		Rejects:      *rejects,
+		MemoryBudget: spill.budget(),
+		TempDir:      *spill.tempDir,
+	}, &cliReporter{})
+}
+
+func runCountrySplitCommand(ctx context.Context, args []string) (interface{}, error) {
+	fs := newFlagSet("country-split")
+	input := fs.String("i", "", "要拆分的文件")
+	outputDir := fs.String("outdir", "", "输出目录")
+	rules := fs.String("rules", "", "区号规则文件（JSON 或 CSV），默认使用程序旁边的 "+engine.CountryRulesFile)
+	dedup := fs.Bool("dedup", false, "去除重复号码")
+	by := fs.String("by", "country", "分组方式：country（按国家区号）、carrier（按中国大陆号段的运营商）、location（按中国大陆归属地）")
+	regions := fs.Bool("regions", false, "美国和加拿大的号码按州/省拆分（美国_加州.txt）")
+	city := fs.Bool("city", false, "按归属地拆分时细分到城市（广东_深圳.txt）")
+	subParts := fs.Int("parts", 0, "每个国家再平均拆分为几份，输出到国家子文件夹（美国/美国_part1.txt）")
+	subLines := fs.Int("lines", 0, "每个国家再按每份最多行数拆分，输出到国家子文件夹")
+	typeFlag := addTypeFlag(fs)
+	rejects := addRejectsFlag(fs)
+	norm := addNormalizeFlags(fs)
+	spill := addSpillFlags(fs)
+	if err := parseFlags(fs, args, "i", "outdir"); err != nil {
+		return nil, err
+	}
+	normalize, err := norm.options()
+	if err != nil {
+		return nil, err
+	}
+	mode, err := engine.ParseCountrySplitMode(*by)
+	if err != nil {
+		return nil, usageError{err.Error()}
+	}
+	types, err := parseTypeFlag(typeFlag)
+	if err != nil {
+		return nil, err
+	}
+	if *subParts < 0 || *subLines < 0 || *subParts > 0 && *subLines > 0 {
+		return nil, usageErrorf("-parts 和 -lines 只能指定一个")
+	}
+
+	var countries []engine.CountryCode
+	if *rules != "" {
+		if countries, err = engine.LoadCountryCodes(*rules); err != nil {
+			return nil, err
+		}
+		for _, issue := range engine.ValidateCountryCodes(countries) {
+			if issue.Fatal {
+				return nil, fmt.Errorf("区号表有冲突: %s", issue.Message)
+			}
+		}
+	}
+
+	return engine.CountrySplit(ctx, engine.CountrySplitOptions{
+		Input:        *input,
+		OutputDir:    *outputDir,
+		Dedup:        *dedup,
+		Mode:         mode,
+		Normalize:    normalize,
+		Countries:    countries,
+		NANPRegions:  *regions,
+		ByCity:       *city,
+		Types:        types,
+		Rejects:      *rejects,
+		SubParts:     *subParts,
+		SubLines:     *subLines,
+		MemoryBudget: spill.budget(),
+		TempDir:      *spill.tempDir,
+	}, &cliReporter{})
+}
+
+func runNumberAddCommand(ctx context.Context, args []string) (interface{}, error) {
+	fs := newFlagSet("number-add")
+	input := fs.String("i", "", "要处理的文件")
+	output := fs.String("o", "", "输出文件")
+	position := fs.Int("position", -1, "增加位置，0 表示在开头增加")
+	digit := fs.String("digit", "", "要增加的字符，为空则随机 0-9")
+	removeEmpty := fs.Bool("remove-empty", false, "去除空行")
+	rejects := addRejectsFlag(fs)
+	if err := parseFlags(fs, args, "i", "o"); err != nil {
+		return nil, err
+	}
+	if *position < 0 {
+		return nil, usageErrorf("请输入有效的位置数字（大于等于0的整数）")
+	}
+
+	return engine.NumberAdd(ctx, engine.NumberAddOptions{
+		Input:       *input,
+		Output:      *output,
+		Position:    *position,
+		Digit:       strings.TrimSpace(*digit),
+		RemoveEmpty: *removeEmpty,
+		Rejects:     *rejects,
+	}, &cliReporter{})
+}
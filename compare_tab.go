@@ -1,17 +1,17 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
-	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
 )
 
 // 创建文件重复比较标签页
@@ -77,9 +77,10 @@ func (a *App) createCompareTab() *fyne.Container {
 	compareBtn.Importance = widget.HighImportance
 
 	// 进度区域
-	a.compareProgress = widget.NewProgressBar()
+	a.compareProgress = newProgressBar()
 	a.compareStatus = widget.NewLabel("📋 就绪")
 	a.compareStatus.TextStyle = fyne.TextStyle{Italic: true}
+	a.compareRejects = newRejectsCheck()
 
 	// 顶部说明
 	topSection := container.NewVBox(
@@ -108,7 +109,9 @@ func (a *App) createCompareTab() *fyne.Container {
 	// 底部控制区域
 	bottomSection := container.NewVBox(
 		widget.NewSeparator(),
-		container.NewHBox(widget.NewLabel(""), compareBtn),
+		a.compareRejects,
+		a.compareNormalize.newWidget(a.window),
+		container.NewHBox(widget.NewLabel(""), compareBtn, a.compareTask.newStopButton()),
 		widget.NewSeparator(),
 		widget.NewLabel("📊 进度状态:"),
 		a.compareProgress,
@@ -156,188 +159,54 @@ func (a *App) startCompare() {
 		return
 	}
 
+	ctx, ok := a.compareTask.begin()
+	if !ok {
+		dialog.ShowInformation("提示", "比较任务正在进行中", a.window)
+		return
+	}
+
 	go func() {
+		defer a.compareTask.end()
 		a.compareStatus.SetText("🔄 正在比较文件...")
-		a.compareProgress.SetValue(0)
+		resetProgress(a.compareProgress)
 
-		err := a.performCompare()
-		if err != nil {
+		note, err := a.performCompare(ctx)
+		if err == engine.ErrCanceled {
+			a.compareStatus.SetText("⏹ 已停止：未完成的输出文件已删除，源文件未改动")
+			return
+		} else if err != nil {
 			a.compareStatus.SetText("❌ 比较失败: " + err.Error())
 			dialog.ShowError(err, a.window)
 		} else {
-			a.compareStatus.SetText("✅ 比较完成")
+			a.compareStatus.SetText("✅ 比较完成" + note)
 			dialog.ShowInformation("完成", "文件比较成功！\n已生成相同内容和不同内容的文件", a.window)
 		}
 		a.compareProgress.SetValue(1.0)
 	}()
 }
 
-// 执行文件比较操作
-func (a *App) performCompare() error {
+// 执行文件比较操作，返回状态栏中的校验说明
+func (a *App) performCompare(ctx context.Context) (string, error) {
 	// 选择输出目录
 	outputDir, err := nativeDialog.Directory().Title("选择输出文件夹").Browse()
 	if err != nil {
-		return fmt.Errorf("选择输出目录失败: %v", err)
-	}
-
-	// 读取第一个文件
-	file1Lines, err := a.readFileLines(a.compareFile1)
-	if err != nil {
-		return fmt.Errorf("读取文件1失败: %v", err)
+		return "", fmt.Errorf("选择输出目录失败: %v", err)
 	}
 
-	// 读取第二个文件
-	file2Lines, err := a.readFileLines(a.compareFile2)
+	result, err := engine.Compare(ctx, engine.CompareOptions{
+		File1:     a.compareFile1,
+		File2:     a.compareFile2,
+		OutputDir: outputDir,
+		Normalize: a.compareNormalize.options(),
+		Rejects:   a.compareRejects.Checked,
+	}, widgetReporter{a.compareProgress, a.compareStatus})
 	if err != nil {
-		return fmt.Errorf("读取文件2失败: %v", err)
-	}
-
-	// 使用高效的集合算法进行比较
-	file1Set := make(map[string]bool)
-	file2Set := make(map[string]bool)
-	sameSet := make(map[string]bool) // 用于去重相同内容
-
-	totalLines := len(file1Lines) + len(file2Lines)
-	processedLines := 0
-
-	// 构建文件1的集合
-	for _, line := range file1Lines {
-		file1Set[line] = true
-		processedLines++
-		if processedLines%1000 == 0 {
-			progress := float64(processedLines) / float64(totalLines) * 0.3 // 30%用于构建集合
-			a.compareProgress.SetValue(progress)
-		}
-	}
-
-	// 构建文件2的集合
-	for _, line := range file2Lines {
-		file2Set[line] = true
-		processedLines++
-		if processedLines%1000 == 0 {
-			progress := float64(processedLines) / float64(totalLines) * 0.3
-			a.compareProgress.SetValue(progress)
-		}
-	}
-
-	a.compareProgress.SetValue(0.3) // 集合构建完成
-
-	// 找出相同和不同的内容
-	var sameLines []string
-	var diffLines []string
-
-	// 检查文件1中的每一行
-	processedLines = 0
-	for _, line := range file1Lines {
-		if file2Set[line] {
-			// 相同内容（使用map去重，O(1)复杂度）
-			if !sameSet[line] {
-				sameSet[line] = true
-				sameLines = append(sameLines, line)
-			}
-		} else {
-			// 文件1独有的内容
-			diffLines = append(diffLines, line)
-		}
-
-		processedLines++
-		if processedLines%1000 == 0 {
-			progress := 0.3 + float64(processedLines)/float64(len(file1Lines))*0.35 // 30%-65%
-			a.compareProgress.SetValue(progress)
-		}
-	}
-
-	a.compareProgress.SetValue(0.65) // 文件1处理完成
-
-	// 检查文件2中独有的内容
-	processedLines = 0
-	for _, line := range file2Lines {
-		if !file1Set[line] {
-			diffLines = append(diffLines, line)
-		}
-
-		processedLines++
-		if processedLines%1000 == 0 {
-			progress := 0.65 + float64(processedLines)/float64(len(file2Lines))*0.25 // 65%-90%
-			a.compareProgress.SetValue(progress)
-		}
-	}
-
-	a.compareProgress.SetValue(0.9) // 比较完成，准备写入文件
-
-	// 生成输出文件名
-	baseFileName1 := strings.TrimSuffix(filepath.Base(a.compareFile1), filepath.Ext(a.compareFile1))
-	baseFileName2 := strings.TrimSuffix(filepath.Base(a.compareFile2), filepath.Ext(a.compareFile2))
-
-	sameFileName := filepath.Join(outputDir, fmt.Sprintf("%s_%s_相同内容.txt", baseFileName1, baseFileName2))
-	diffFileName := filepath.Join(outputDir, fmt.Sprintf("%s_%s_不同内容.txt", baseFileName1, baseFileName2))
-
-	// 写入相同内容文件
-	err = a.writeLinesToFile(sameFileName, sameLines)
-	if err != nil {
-		return fmt.Errorf("写入相同内容文件失败: %v", err)
-	}
-
-	// 写入不同内容文件
-	err = a.writeLinesToFile(diffFileName, diffLines)
-	if err != nil {
-		return fmt.Errorf("写入不同内容文件失败: %v", err)
+		return "", err
 	}
 
 	fmt.Printf("✅ 比较完成:\n")
-	fmt.Printf("   相同内容: %d 行 -> %s\n", len(sameLines), filepath.Base(sameFileName))
-	fmt.Printf("   不同内容: %d 行 -> %s\n", len(diffLines), filepath.Base(diffFileName))
-
-	return nil
-}
-
-// 读取文件所有行
-func (a *App) readFileLines(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-
-	// 设置更大的缓冲区以处理长行，避免 "token too long" 错误
-	buf := make([]byte, 0, 128*1024) // 128KB初始缓冲区
-	scanner.Buffer(buf, 2*1024*1024) // 2MB最大行长度
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" { // 跳过空行
-			lines = append(lines, line)
-		}
-	}
-
-	return lines, scanner.Err()
-}
-
-// 将行写入文件
-func (a *App) writeLinesToFile(filePath string, lines []string) error {
-	// 删除已存在的文件
-	if _, err := os.Stat(filePath); err == nil {
-		os.Remove(filePath)
-	}
-
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
-
-	for _, line := range lines {
-		_, err := writer.WriteString(line + "\n")
-		if err != nil {
-			return err
-		}
-	}
+	fmt.Printf("   相同内容: %d 行 -> %s\n", result.SameLines, filepath.Base(result.SameFile))
+	fmt.Printf("   不同内容: %d 行 -> %s\n", result.DiffLines, filepath.Base(result.DiffFile))
 
-	return writer.Flush()
+	return rejectsNote(a.compareRejects.Checked, result.Rejected, result.RejectsFile), nil
 }
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// 其他系统的程序始终有控制台，无需处理
+func attachConsole() {}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var procAttachConsole = syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+
+// AttachConsole 的参数：连接到父进程（启动本程序的 cmd 或 PowerShell）的控制台
+const attachParentProcess = ^uint32(0)
+
+// 用 -H windowsgui 打包的 exe 没有控制台，命令行模式下连接到父进程的控制台，
+// 否则在 cmd 或 PowerShell 中看不到 JSON 结果和错误信息。
+// 标准输出已重定向到文件或管道时保持不变。
+func attachConsole() {
+	stdout, stderr := stdHandleValid(syscall.STD_OUTPUT_HANDLE), stdHandleValid(syscall.STD_ERROR_HANDLE)
+	if stdout && stderr {
+		return
+	}
+	if r, _, _ := procAttachConsole.Call(uintptr(attachParentProcess)); r == 0 {
+		return // 没有父控制台，如双击运行
+	}
+	if !stdout {
+		if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+			os.Stdout = f
+		}
+	}
+	if !stderr {
+		if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+			os.Stderr = f
+		}
+	}
+}
+
+func stdHandleValid(id int) bool {
+	h, err := syscall.GetStdHandle(id)
+	return err == nil && h != 0 && h != syscall.InvalidHandle
+}
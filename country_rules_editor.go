@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
+)
+
+// 启动时加载程序旁边的区号规则文件，失败时使用内置默认表
+func loadCountryRules() {
+	path, err := engine.LoadCountryRules()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ 区号规则文件加载失败，使用内置区号表: %v\n", err)
+		return
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "✅ 已加载区号规则文件: %s\n", path)
+	}
+}
+
+// 区号表编辑器：增删和排序国家、编辑前缀、导入导出、校验冲突
+type countryRulesEditor struct {
+	window   fyne.Window
+	codes    []engine.CountryCode // 编辑中的区号表，保存后才生效
+	selected int
+
+	list          *widget.List
+	nameEntry     *widget.Entry
+	prefixesEntry *widget.Entry
+	status        *widget.Label
+}
+
+// 打开区号表编辑窗口
+func (a *App) showCountryRulesEditor() {
+	e := &countryRulesEditor{
+		window:   fyne.CurrentApp().NewWindow("区号表编辑"),
+		codes:    engine.CountryCodes(),
+		selected: -1,
+	}
+	e.window.SetContent(e.build())
+	e.window.Resize(fyne.NewSize(760, 600))
+	e.window.CenterOnScreen()
+	e.window.Show()
+}
+
+func (e *countryRulesEditor) build() fyne.CanvasObject {
+	e.list = widget.NewList(
+		func() int { return len(e.codes) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			c := e.codes[id]
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s（%d 个前缀）", c.Name, len(c.Prefixes)))
+		},
+	)
+	e.list.OnSelected = e.selectCountry
+
+	e.nameEntry = widget.NewEntry()
+	e.nameEntry.OnChanged = func(text string) {
+		if e.selected >= 0 {
+			e.codes[e.selected].Name = strings.TrimSpace(text)
+			e.list.RefreshItem(e.selected)
+		}
+	}
+	e.prefixesEntry = widget.NewMultiLineEntry()
+	e.prefixesEntry.SetPlaceHolder("每行一个前缀，也可用空格或逗号分隔，如：\n1201\n1202\n可填写其他国家前缀下的细分号段（如 +7 下的 76、77），按最长前缀归属")
+	e.prefixesEntry.OnChanged = func(text string) {
+		if e.selected >= 0 {
+			e.codes[e.selected].Prefixes = splitPrefixes(text)
+			e.list.RefreshItem(e.selected)
+		}
+	}
+	e.status = widget.NewLabel("")
+	e.setEditorEnabled(false)
+	e.updateStatus()
+
+	listButtons := container.NewGridWithColumns(4,
+		widget.NewButton("➕ 添加", e.addCountry),
+		widget.NewButton("🗑 删除", e.removeCountry),
+		widget.NewButton("⬆ 上移", func() { e.moveCountry(-1) }),
+		widget.NewButton("⬇ 下移", func() { e.moveCountry(1) }),
+	)
+	left := container.NewBorder(widget.NewLabel("🌍 国家（最长前缀优先匹配）:"), listButtons, nil, nil, e.list)
+
+	right := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel("国家名称（即输出文件名）:"),
+			e.nameEntry,
+			widget.NewLabel("号码前缀（含国家码，不带 +）:"),
+		),
+		nil, nil, nil,
+		e.prefixesEntry,
+	)
+
+	split := container.NewHSplit(left, right)
+	split.SetOffset(0.4)
+
+	saveBtn := widget.NewButton("💾 保存并应用", e.save)
+	saveBtn.Importance = widget.HighImportance
+	bottom := container.NewVBox(
+		widget.NewSeparator(),
+		container.NewHBox(
+			widget.NewButton("📥 导入", e.importRules),
+			widget.NewButton("📤 导出", e.exportRules),
+			widget.NewButton("✅ 校验", e.showIssues),
+			widget.NewButton("↩ 恢复默认", e.resetDefault),
+			saveBtn,
+		),
+		e.status,
+	)
+
+	return container.NewBorder(nil, bottom, nil, nil, split)
+}
+
+// 按换行、空格或逗号拆分前缀
+func splitPrefixes(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ' ' || r == '\t' || r == ',' || r == '，'
+	})
+}
+
+func (e *countryRulesEditor) selectCountry(id widget.ListItemID) {
+	e.selected = -1 // 填充输入框时不回写
+	c := e.codes[id]
+	e.nameEntry.SetText(c.Name)
+	e.prefixesEntry.SetText(strings.Join(c.Prefixes, "\n"))
+	e.selected = id
+	e.setEditorEnabled(true)
+}
+
+func (e *countryRulesEditor) setEditorEnabled(enabled bool) {
+	if enabled {
+		e.nameEntry.Enable()
+		e.prefixesEntry.Enable()
+		return
+	}
+	e.nameEntry.SetText("")
+	e.prefixesEntry.SetText("")
+	e.nameEntry.Disable()
+	e.prefixesEntry.Disable()
+}
+
+// 重新加载列表，清除选择
+func (e *countryRulesEditor) reload() {
+	e.selected = -1
+	e.list.UnselectAll()
+	e.setEditorEnabled(false)
+	e.list.Refresh()
+	e.updateStatus()
+}
+
+func (e *countryRulesEditor) updateStatus() {
+	prefixes := 0
+	for _, c := range e.codes {
+		prefixes += len(c.Prefixes)
+	}
+	e.status.SetText(fmt.Sprintf("📋 共 %d 个国家，%d 个前缀（保存后生效）", len(e.codes), prefixes))
+}
+
+func (e *countryRulesEditor) addCountry() {
+	e.codes = append(e.codes, engine.CountryCode{Name: fmt.Sprintf("新国家%d", len(e.codes)+1)})
+	e.list.Refresh()
+	e.list.Select(len(e.codes) - 1)
+	e.updateStatus()
+}
+
+func (e *countryRulesEditor) removeCountry() {
+	id := e.selected
+	if id < 0 {
+		return
+	}
+	e.codes = append(e.codes[:id], e.codes[id+1:]...)
+	e.reload()
+}
+
+func (e *countryRulesEditor) moveCountry(delta int) {
+	id := e.selected
+	target := id + delta
+	if id < 0 || target < 0 || target >= len(e.codes) {
+		return
+	}
+	e.codes[id], e.codes[target] = e.codes[target], e.codes[id]
+	e.list.Refresh()
+	e.list.Select(target)
+}
+
+func (e *countryRulesEditor) importRules() {
+	path, err := nativeDialog.File().Filter("区号规则", "json", "csv").Title("导入区号规则").Load()
+	if err != nil {
+		return
+	}
+	codes, err := engine.LoadCountryCodes(path)
+	if err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.codes = codes
+	e.reload()
+	e.status.SetText(fmt.Sprintf("📥 已导入 %s，共 %d 个国家（保存后生效）", filepath.Base(path), len(codes)))
+}
+
+func (e *countryRulesEditor) exportRules() {
+	path, err := nativeDialog.File().Filter("区号规则", "json", "csv").Title("导出区号规则").Save()
+	if err != nil {
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".json" && ext != ".csv" {
+		path += ".json"
+	}
+	if err := engine.SaveCountryCodes(path, e.codes); err != nil {
+		dialog.ShowError(err, e.window)
+		return
+	}
+	e.status.SetText("📤 已导出到 " + path)
+}
+
+func (e *countryRulesEditor) resetDefault() {
+	dialog.ShowConfirm("恢复默认", "用内置区号表替换当前编辑的内容？", func(ok bool) {
+		if ok {
+			e.codes = engine.DefaultCountryCodes()
+			e.reload()
+		}
+	}, e.window)
+}
+
+// 校验结果：冲突和提醒分开列出
+func (e *countryRulesEditor) issueText(issues []engine.CountryIssue) string {
+	var fatal, warn []string
+	for _, issue := range issues {
+		if issue.Fatal {
+			fatal = append(fatal, "❌ "+issue.Message)
+		} else {
+			warn = append(warn, "⚠️ "+issue.Message)
+		}
+	}
+	return strings.Join(append(fatal, warn...), "\n")
+}
+
+func (e *countryRulesEditor) showIssues() {
+	issues := engine.ValidateCountryCodes(e.codes)
+	if len(issues) == 0 {
+		dialog.ShowInformation("校验结果", "✅ 没有发现冲突或重复的前缀", e.window)
+		return
+	}
+	e.showIssueDialog("校验结果", issues)
+}
+
+func (e *countryRulesEditor) showIssueDialog(title string, issues []engine.CountryIssue) {
+	text := widget.NewLabel(e.issueText(issues))
+	scroll := container.NewVScroll(text)
+	scroll.SetMinSize(fyne.NewSize(560, 320))
+	dialog.ShowCustom(title, "关闭", scroll, e.window)
+}
+
+func (e *countryRulesEditor) save() {
+	issues := engine.ValidateCountryCodes(e.codes)
+	for _, issue := range issues {
+		if issue.Fatal {
+			e.showIssueDialog("存在冲突，无法保存", issues)
+			return
+		}
+	}
+
+	write := func() {
+		path := engine.CountryRulesPath()
+		if err := engine.SaveCountryCodes(path, e.codes); err != nil {
+			dialog.ShowError(err, e.window)
+			return
+		}
+		engine.SetCountryCodes(e.codes)
+		e.status.SetText("💾 已保存并生效: " + path)
+		fmt.Printf("✅ 区号表已保存: %s\n", path)
+	}
+	if len(issues) == 0 {
+		write()
+		return
+	}
+	dialog.ShowConfirm("存在重复的前缀",
+		fmt.Sprintf("发现 %d 处重复或已被包含的前缀，仍然保存？\n点击“校验”可查看详情。", len(issues)),
+		func(ok bool) {
+			if ok {
+				write()
+			}
+		}, e.window)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"ts-merge-go/engine"
+)
+
+// 柱状图显示的国家数量
+const countryChartTop = 10
+
+// 柱状图最长的柱子宽度
+const countryChartWidth = 360
+
+// 区号拆分统计结果：统计表和号码最多的国家（或运营商）柱状图
+func newCountrySummaryView(result *engine.CountrySplitResult) fyne.CanvasObject {
+	tabs := container.NewAppTabs(
+		container.NewTabItem("📋 统计表", newCountrySummaryTable(result)),
+		container.NewTabItem(fmt.Sprintf("📊 前 %d 个%s", countryChartTop, result.GroupBy), newCountryBarChart(result.Countries)),
+	)
+	text := fmt.Sprintf("共 %d 个%s，写入 %d 个号码，去除重复 %d，长度异常 %d",
+		len(result.Countries), result.GroupBy, result.LinesWritten, result.Duplicates, result.InvalidLength)
+	if result.NonMobile > 0 {
+		text += fmt.Sprintf("，丢弃非手机号 %d", result.NonMobile)
+	}
+	if result.UnknownType > 0 {
+		text += fmt.Sprintf("，无法判断类型 %d（已保留）", result.UnknownType)
+	}
+	if result.Rejected > 0 {
+		text += fmt.Sprintf("，校验不合格 %d（%s）", result.Rejected, filepath.Base(result.RejectsFile))
+	}
+	summary := widget.NewLabel(text + "\n统计表已保存: " + result.SummaryCSV)
+	summary.Wrapping = fyne.TextWrapWord
+	return container.NewVBox(widget.NewLabel("📈 拆分统计:"), summary, tabs)
+}
+
+// 统计表，第一行为表头，最后一行为合计
+func newCountrySummaryTable(result *engine.CountrySplitResult) fyne.CanvasObject {
+	header := []string{result.GroupBy, "匹配前缀数", "号码数", "占比", "去除重复", "长度异常"}
+	rows := [][]string{header}
+	for _, c := range result.Countries {
+		rows = append(rows, []string{
+			c.Label(),
+			strconv.Itoa(len(c.MatchedPrefixes)),
+			strconv.Itoa(c.Count),
+			engine.FormatShare(c.Share),
+			strconv.Itoa(c.Duplicates),
+			strconv.Itoa(c.InvalidLength),
+		})
+	}
+	total := engine.FormatShare(0)
+	if result.LinesWritten > 0 {
+		total = engine.FormatShare(1)
+	}
+	rows = append(rows, []string{
+		"合计", "",
+		strconv.Itoa(result.LinesWritten),
+		total,
+		strconv.Itoa(result.Duplicates),
+		strconv.Itoa(result.InvalidLength),
+	})
+
+	table := widget.NewTable(
+		func() (int, int) { return len(rows), len(header) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			label.SetText(rows[id.Row][id.Col])
+			label.TextStyle = fyne.TextStyle{Bold: id.Row == 0 || id.Row == len(rows)-1}
+			label.Refresh()
+		},
+	)
+	table.SetColumnWidth(0, 180)
+	for col := 1; col < len(header); col++ {
+		table.SetColumnWidth(col, 100)
+	}
+	return container.NewGridWrap(fyne.NewSize(700, 240), table)
+}
+
+// 号码最多的国家的横向柱状图，countries 已按号码数从多到少排列
+func newCountryBarChart(countries []engine.CountryCount) fyne.CanvasObject {
+	if len(countries) > countryChartTop {
+		countries = countries[:countryChartTop]
+	}
+	if len(countries) == 0 || countries[0].Count == 0 {
+		return container.NewVBox(widget.NewLabel("没有号码"))
+	}
+	form := container.New(layout.NewFormLayout())
+	top := float32(countries[0].Count)
+	for _, c := range countries {
+		bar := canvas.NewRectangle(theme.PrimaryColor())
+		bar.SetMinSize(fyne.NewSize(countryChartWidth*float32(c.Count)/top, 18))
+		value := widget.NewLabel(fmt.Sprintf("%d（%s）", c.Count, engine.FormatShare(c.Share)))
+		form.Add(widget.NewLabel(c.Label()))
+		form.Add(container.NewHBox(container.NewCenter(bar), value))
+	}
+	return container.NewGridWrap(fyne.NewSize(700, 240), container.NewVScroll(form))
+}
@@ -1,10 +1,10 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -12,6 +12,8 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
 )
 
 // 创建区号拆分标签页
@@ -45,6 +47,45 @@ func (a *App) createCountrySplitTab() *fyne.Container {
 		}
 	})
 
+	a.countrySplitDedup = widget.NewCheck("🔄 去除重复号码", nil)
+	a.countrySplitRejects = newRejectsCheck()
+	a.countrySplitTypes = newTypeModeSelect()
+	a.countrySplitRegions = widget.NewCheck("🗺 美国、加拿大号码按州/省拆分（如 美国_加州.txt）", nil)
+	a.countrySplitByCity = widget.NewCheck("🏙 按城市细分（如 广东_深圳.txt），否则每个省份一个文件", nil)
+	a.countrySplitLocationInfo = widget.NewLabel(locationInfoText())
+	a.countrySplitMode = widget.NewRadioGroup(engine.CountrySplitModes, func(selected string) {
+		// 州/省拆分只在按国家拆分时有效，城市细分只在按归属地拆分时有效
+		mode := a.countrySplitSelectedMode()
+		if mode == engine.SplitByCountry {
+			a.countrySplitRegions.Enable()
+		} else {
+			a.countrySplitRegions.Disable()
+		}
+		if mode == engine.SplitByLocation {
+			a.countrySplitByCity.Enable()
+		} else {
+			a.countrySplitByCity.Disable()
+		}
+	})
+	a.countrySplitSubValue = widget.NewEntry()
+	a.countrySplitSubMode = widget.NewSelect(countrySubSplitModes, func(string) {
+		switch a.countrySplitSubMode.SelectedIndex() {
+		case 1:
+			a.countrySplitSubValue.SetPlaceHolder("每个国家拆分的份数，如：3")
+			a.countrySplitSubValue.Enable()
+		case 2:
+			a.countrySplitSubValue.SetPlaceHolder("每个文件最多的行数，如：50000")
+			a.countrySplitSubValue.Enable()
+		default:
+			a.countrySplitSubValue.SetPlaceHolder("")
+			a.countrySplitSubValue.Disable()
+		}
+	})
+	a.countrySplitSubMode.SetSelectedIndex(0)
+	a.countrySplitMode.Horizontal = true
+	a.countrySplitMode.Required = true
+	a.countrySplitMode.SetSelected(engine.CountrySplitModes[engine.SplitByCountry])
+
 	// 开始拆分按钮
 	splitBtn := widget.NewButtonWithIcon("🌍 开始拆分", nil, func() {
 		if a.countrySplitFile == "" {
@@ -56,8 +97,9 @@ func (a *App) createCountrySplitTab() *fyne.Container {
 	splitBtn.Importance = widget.HighImportance
 
 	// 进度区域
-	a.countrySplitProgress = widget.NewProgressBar()
+	a.countrySplitProgress = newProgressBar()
 	a.countrySplitStatus = widget.NewLabel("📋 就绪")
+	a.countrySplitResults = container.NewVBox()
 	a.countrySplitStatus.TextStyle = fyne.TextStyle{Italic: true}
 
 	// 主布局
@@ -75,17 +117,34 @@ func (a *App) createCountrySplitTab() *fyne.Container {
 		widget.NewLabel("⚙️ 拆分说明:"),
 		widget.NewLabel("• 自动识别手机号的国家区号"),
 		widget.NewLabel("• 按国家分组生成独立文件"),
-		widget.NewLabel("• 支持美国、英国等主要国家"),
-		widget.NewLabel("• 输出文件格式: 国家名.txt"),
+		widget.NewLabel("• 支持美国、英国等主要国家，北美号码（+1）按区号细分到加拿大和加勒比各国"),
+		widget.NewLabel("• 输出文件格式: 国家名.txt；按运营商拆分时为 中国移动.txt 等，非大陆手机号归入 未知运营商.txt"),
+		widget.NewLabel("• 输出目录中同时生成统计表 summary.csv 和 summary.json"),
+		widget.NewLabel("• 每个国家可再按份数或行数拆分，分片放在以国家命名的子文件夹中"),
+		widget.NewButton("✏️ 编辑区号表", a.showCountryRulesEditor),
+		container.NewHBox(widget.NewLabel("拆分方式:"), a.countrySplitMode),
+		a.countrySplitDedup,
+		a.countrySplitRejects,
+		container.NewHBox(widget.NewLabel("📱 号码类型（按手机号只保留或分别输出，如 英国_手机.txt）:"), a.countrySplitTypes),
+		a.countrySplitRegions,
+		a.countrySplitByCity,
+		container.NewGridWithColumns(3,
+			widget.NewLabel("✂️ 每个国家再拆分（如 美国/美国_part1.txt）:"),
+			a.countrySplitSubMode,
+			a.countrySplitSubValue,
+		),
+		container.NewHBox(a.countrySplitLocationInfo, widget.NewButton("📥 导入归属地库", a.importLocations)),
+		a.countrySplitNormalize.newWidget(a.window),
 	)
 
 	bottomSection := container.NewVBox(
 		widget.NewSeparator(),
-		container.NewHBox(widget.NewLabel(""), splitBtn),
+		container.NewHBox(widget.NewLabel(""), splitBtn, a.countrySplitTask.newStopButton()),
 		widget.NewSeparator(),
 		widget.NewLabel("📊 进度状态:"),
 		a.countrySplitProgress,
 		a.countrySplitStatus,
+		a.countrySplitResults,
 	)
 
 	return container.NewVBox(
@@ -157,9 +216,22 @@ func (a *App) startCountrySplit() {
 		return
 	}
 
+	if _, _, err := a.countrySplitSubOptions(); err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	ctx, ok := a.countrySplitTask.begin()
+	if !ok {
+		dialog.ShowInformation("提示", "区号拆分任务正在进行中", a.window)
+		return
+	}
+
 	go func() {
+		defer a.countrySplitTask.end()
 		a.countrySplitStatus.SetText("🔄 正在按区号拆分文件...")
-		a.countrySplitProgress.SetValue(0)
+		resetProgress(a.countrySplitProgress)
+		a.countrySplitResults.RemoveAll()
 
 		// 选择输出目录
 		outputDir, err := nativeDialog.Directory().Title("选择拆分文件的输出文件夹").Browse()
@@ -168,372 +240,83 @@ func (a *App) startCountrySplit() {
 			return
 		}
 
-		err = a.performCountrySplit(outputDir)
-		if err != nil {
+		note, err := a.performCountrySplit(ctx, outputDir)
+		if err == engine.ErrCanceled {
+			a.countrySplitStatus.SetText("⏹ 已停止：本次生成的国家文件已删除，源文件未改动")
+			return
+		} else if err != nil {
 			a.countrySplitStatus.SetText("❌ 拆分失败: " + err.Error())
 			dialog.ShowError(err, a.window)
 		} else {
-			a.countrySplitStatus.SetText("✅ 拆分完成")
-			dialog.ShowInformation("完成", "按国家区号拆分成功！\n已生成各国家的独立文件", a.window)
+			a.countrySplitStatus.SetText("✅ 拆分完成" + note)
+			dialog.ShowInformation("完成", "按国家区号拆分成功！\n已生成各国家的独立文件和统计表 summary.csv", a.window)
 		}
 		a.countrySplitProgress.SetValue(1.0)
 	}()
 }
 
-// 国家区号映射表
-type CountryCode struct {
-	Name     string   // 国家名称
-	Prefixes []string // 手机号前缀列表
-}
-
-// 获取国家区号映射表
-func getCountryCodes() []CountryCode {
-	return []CountryCode{
-		// 美国
-		{
-			Name: "美国",
-			Prefixes: []string{
-				"1201", "1202", "1203", "1205", "1206", "1207", "1208", "1209", "1210",
-				"1212", "1213", "1214", "1215", "1216", "1217", "1218", "1219", "1224",
-				"1225", "1228", "1229", "1231", "1234", "1239", "1240", "1248", "1251",
-				"1252", "1253", "1254", "1256", "1260", "1262", "1267", "1269", "1270",
-				"1276", "1281", "1301", "1302", "1303", "1304", "1305", "1307", "1308",
-				"1309", "1310", "1312", "1313", "1314", "1315", "1316", "1317", "1318",
-				"1319", "1320", "1321", "1323", "1325", "1330", "1331", "1334", "1336",
-				"1337", "1339", "1341", "1347", "1351", "1352", "1360", "1361",
-				"1364", "1380", "1385", "1386", "1401", "1402", "1404", "1405", "1406",
-				"1407", "1408", "1409", "1410", "1412", "1413", "1414", "1415", "1417",
-				"1419", "1423", "1424", "1425", "1430", "1432", "1434", "1435", "1440",
-				"1442", "1443", "1458", "1463", "1464", "1469", "1470", "1475", "1478",
-				"1479", "1480", "1484", "1501", "1502", "1503", "1504", "1505", "1507",
-				"1508", "1509", "1510", "1512", "1513", "1515", "1516", "1517", "1518",
-				"1520", "1530", "1531", "1534", "1539", "1540", "1541", "1551", "1559",
-				"1561", "1562", "1563", "1564", "1567", "1570", "1571", "1573", "1574",
-				"1575", "1580", "1585", "1586", "1601", "1602", "1603", "1605", "1606",
-				"1607", "1608", "1609", "1610", "1612", "1614", "1615", "1616", "1617",
-				"1618", "1619", "1620", "1623", "1626", "1628", "1629", "1630", "1631",
-				"1636", "1641", "1646", "1650", "1651", "1657", "1660", "1661", "1662",
-				"1667", "1669", "1678", "1681", "1682", "1689", "1701", "1702",
-				"1703", "1704", "1706", "1707", "1708", "1712", "1713", "1714", "1715",
-				"1716", "1717", "1718", "1719", "1720", "1724", "1725", "1727", "1731",
-				"1732", "1734", "1737", "1740", "1743", "1747", "1754", "1757", "1760",
-				"1762", "1763", "1764", "1765", "1769", "1770", "1772", "1773", "1774",
-				"1775", "1779", "1781", "1785", "1786", "1801", "1802", "1803",
-				"1804", "1805", "1806", "1810", "1812", "1813", "1814", "1815",
-				"1816", "1817", "1818", "1828", "1830", "1831", "1832", "1843", "1845",
-				"1847", "1848", "1850", "1856", "1857", "1858", "1859", "1860", "1862",
-				"1863", "1864", "1865", "1870", "1872", "1878", "1901", "1903", "1904",
-				"1906", "1907", "1908", "1909", "1910", "1912", "1913", "1914", "1915",
-				"1916", "1917", "1918", "1919", "1920", "1925", "1928", "1929", "1930",
-				"1931", "1934", "1936", "1937", "1940", "1941", "1947", "1949", "1951",
-				"1952", "1954", "1956", "1959", "1970", "1971", "1972", "1973", "1978",
-				"1979", "1980", "1984", "1985", "1989",
-			},
-		},
-		// 加拿大
-		{
-			Name: "加拿大",
-			Prefixes: []string{
-				"1403", "1587", "1825", // 阿尔伯塔省
-				"1236", "1250", "1604", "1672", "1778", // 不列颠哥伦比亚省
-				"1204", "1431", // 马尼托巴省
-				"1506",         // 新不伦瑞克省
-				"1709",         // 纽芬兰和拉布拉多省
-				"1782", "1902", // 新斯科舍省
-				"1226", "1249", "1289", "1343", "1365", "1416", "1437", "1519", "1548", "1613", "1647", "1705", "1807", "1905", // 安大略省
-				"1418", "1438", "1450", "1514", "1579", "1581", "1819", "1873", // 魁北克省
-				"1306", "1639", // 萨斯喀彻温省
-				"1867", // 西北地区、努纳武特地区、育空地区
-			},
-		},
-		// 俄罗斯/哈萨克斯坦 +7
-		{Name: "俄罗斯", Prefixes: []string{"7"}},
-		// 埃及 +20
-		{Name: "埃及", Prefixes: []string{"20"}},
-		// 南非 +27
-		{Name: "南非", Prefixes: []string{"27"}},
-		// 希腊 +30
-		{Name: "希腊", Prefixes: []string{"30"}},
-		// 荷兰 +31
-		{Name: "荷兰", Prefixes: []string{"31"}},
-		// 比利时 +32
-		{Name: "比利时", Prefixes: []string{"32"}},
-		// 法国 +33
-		{Name: "法国", Prefixes: []string{"33"}},
-		// 西班牙 +34
-		{Name: "西班牙", Prefixes: []string{"34"}},
-		// 匈牙利 +36
-		{Name: "匈牙利", Prefixes: []string{"36"}},
-		// 意大利 +39
-		{Name: "意大利", Prefixes: []string{"39"}},
-		// 罗马尼亚 +40
-		{Name: "罗马尼亚", Prefixes: []string{"40"}},
-		// 瑞士 +41
-		{Name: "瑞士", Prefixes: []string{"41"}},
-		// 奥地利 +43
-		{Name: "奥地利", Prefixes: []string{"43"}},
-		// 英国 +44
-		{Name: "英国", Prefixes: []string{"44"}},
-		// 丹麦 +45
-		{Name: "丹麦", Prefixes: []string{"45"}},
-		// 瑞典 +46
-		{Name: "瑞典", Prefixes: []string{"46"}},
-		// 挪威 +47
-		{Name: "挪威", Prefixes: []string{"47"}},
-		// 波兰 +48
-		{Name: "波兰", Prefixes: []string{"48"}},
-		// 德国 +49
-		{Name: "德国", Prefixes: []string{"49"}},
-		// 秘鲁 +51
-		{Name: "秘鲁", Prefixes: []string{"51"}},
-		// 墨西哥 +52
-		{Name: "墨西哥", Prefixes: []string{"52"}},
-		// 古巴 +53
-		{Name: "古巴", Prefixes: []string{"53"}},
-		// 阿根廷 +54
-		{Name: "阿根廷", Prefixes: []string{"54"}},
-		// 巴西 +55
-		{Name: "巴西", Prefixes: []string{"55"}},
-		// 智利 +56
-		{Name: "智利", Prefixes: []string{"56"}},
-		// 哥伦比亚 +57
-		{Name: "哥伦比亚", Prefixes: []string{"57"}},
-		// 委内瑞拉 +58
-		{Name: "委内瑞拉", Prefixes: []string{"58"}},
-		// 马来西亚 +60
-		{Name: "马来西亚", Prefixes: []string{"60"}},
-		// 澳大利亚 +61
-		{Name: "澳大利亚", Prefixes: []string{"61"}},
-		// 印度尼西亚 +62
-		{Name: "印度尼西亚", Prefixes: []string{"62"}},
-		// 菲律宾 +63
-		{Name: "菲律宾", Prefixes: []string{"63"}},
-		// 新西兰 +64
-		{Name: "新西兰", Prefixes: []string{"64"}},
-		// 新加坡 +65
-		{Name: "新加坡", Prefixes: []string{"65"}},
-		// 泰国 +66
-		{Name: "泰国", Prefixes: []string{"66"}},
-		// 日本 +81
-		{Name: "日本", Prefixes: []string{"81"}},
-		// 韩国 +82
-		{Name: "韩国", Prefixes: []string{"82"}},
-		// 越南 +84
-		{Name: "越南", Prefixes: []string{"84"}},
-		// 土耳其 +90
-		{Name: "土耳其", Prefixes: []string{"90"}},
-		// 印度 +91
-		{Name: "印度", Prefixes: []string{"91"}},
-		// 巴基斯坦 +92
-		{Name: "巴基斯坦", Prefixes: []string{"92"}},
-		// 阿富汗 +93
-		{Name: "阿富汗", Prefixes: []string{"93"}},
-		// 斯里兰卡 +94
-		{Name: "斯里兰卡", Prefixes: []string{"94"}},
-		// 缅甸 +95
-		{Name: "缅甸", Prefixes: []string{"95"}},
-		// 伊朗 +98
-		{Name: "伊朗", Prefixes: []string{"98"}},
-		// 摩洛哥 +212
-		{Name: "摩洛哥", Prefixes: []string{"212"}},
-		// 阿尔及利亚 +213
-		{Name: "阿尔及利亚", Prefixes: []string{"213"}},
-		// 突尼斯 +216
-		{Name: "突尼斯", Prefixes: []string{"216"}},
-		// 利比亚 +218
-		{Name: "利比亚", Prefixes: []string{"218"}},
-		// 尼日利亚 +234
-		{Name: "尼日利亚", Prefixes: []string{"234"}},
-		// 肯尼亚 +254
-		{Name: "肯尼亚", Prefixes: []string{"254"}},
-		// 坦桑尼亚 +255
-		{Name: "坦桑尼亚", Prefixes: []string{"255"}},
-		// 乌干达 +256
-		{Name: "乌干达", Prefixes: []string{"256"}},
-		// 津巴布韦 +263
-		{Name: "津巴布韦", Prefixes: []string{"263"}},
-		// 葡萄牙 +351
-		{Name: "葡萄牙", Prefixes: []string{"351"}},
-		// 卢森堡 +352
-		{Name: "卢森堡", Prefixes: []string{"352"}},
-		// 爱尔兰 +353
-		{Name: "爱尔兰", Prefixes: []string{"353"}},
-		// 冰岛 +354
-		{Name: "冰岛", Prefixes: []string{"354"}},
-		// 阿尔巴尼亚 +355
-		{Name: "阿尔巴尼亚", Prefixes: []string{"355"}},
-		// 马耳他 +356
-		{Name: "马耳他", Prefixes: []string{"356"}},
-		// 芬兰 +358
-		{Name: "芬兰", Prefixes: []string{"358"}},
-		// 保加利亚 +359
-		{Name: "保加利亚", Prefixes: []string{"359"}},
-		// 立陶宛 +370
-		{Name: "立陶宛", Prefixes: []string{"370"}},
-		// 拉脱维亚 +371
-		{Name: "拉脱维亚", Prefixes: []string{"371"}},
-		// 爱沙尼亚 +372
-		{Name: "爱沙尼亚", Prefixes: []string{"372"}},
-		// 摩尔多瓦 +373
-		{Name: "摩尔多瓦", Prefixes: []string{"373"}},
-		// 白俄罗斯 +375
-		{Name: "白俄罗斯", Prefixes: []string{"375"}},
-		// 乌克兰 +380
-		{Name: "乌克兰", Prefixes: []string{"380"}},
-		// 塞尔维亚 +381
-		{Name: "塞尔维亚", Prefixes: []string{"381"}},
-		// 黑山 +382
-		{Name: "黑山", Prefixes: []string{"382"}},
-		// 克罗地亚 +385
-		{Name: "克罗地亚", Prefixes: []string{"385"}},
-		// 斯洛文尼亚 +386
-		{Name: "斯洛文尼亚", Prefixes: []string{"386"}},
-		// 波黑 +387
-		{Name: "波黑", Prefixes: []string{"387"}},
-		// 马其顿 +389
-		{Name: "马其顿", Prefixes: []string{"389"}},
-		// 捷克 +420
-		{Name: "捷克", Prefixes: []string{"420"}},
-		// 斯洛伐克 +421
-		{Name: "斯洛伐克", Prefixes: []string{"421"}},
-		// 以色列 +972
-		{Name: "以色列", Prefixes: []string{"972"}},
-		// 阿联酋 +971
-		{Name: "阿联酋", Prefixes: []string{"971"}},
-		// 沙特阿拉伯 +966
-		{Name: "沙特阿拉伯", Prefixes: []string{"966"}},
+// 当前选择的拆分方式
+func (a *App) countrySplitSelectedMode() engine.CountrySplitMode {
+	for i, name := range engine.CountrySplitModes {
+		if name == a.countrySplitMode.Selected {
+			return engine.CountrySplitMode(i)
+		}
 	}
+	return engine.SplitByCountry
 }
 
-// 根据手机号前缀识别国家
-func identifyCountry(phoneNumber string) string {
-	countryCodes := getCountryCodes()
+// 每个国家再拆分的方式，顺序与 countrySplitSubOptions 中的判断一致
+var countrySubSplitModes = []string{"不再拆分", "按份数", "按每份行数"}
 
-	// 按前缀长度从长到短排序，优先匹配更长的前缀
-	for _, country := range countryCodes {
-		for _, prefix := range country.Prefixes {
-			if strings.HasPrefix(phoneNumber, prefix) {
-				return country.Name
-			}
+// 每个国家再拆分的份数或每份行数，不再拆分时都为 0
+func (a *App) countrySplitSubOptions() (parts, lines int, err error) {
+	index := a.countrySplitSubMode.SelectedIndex()
+	if index <= 0 {
+		return 0, 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(a.countrySplitSubValue.Text))
+	if err != nil || n <= 0 {
+		if index == 1 {
+			return 0, 0, fmt.Errorf("请输入有效的拆分份数")
 		}
+		return 0, 0, fmt.Errorf("请输入有效的每份行数")
 	}
-
-	return "未知国家"
+	if index == 1 {
+		return n, 0, nil
+	}
+	return 0, n, nil
 }
 
-// 执行按国家区号拆分操作
-func (a *App) performCountrySplit(outputDir string) error {
-	file, err := os.Open(a.countrySplitFile)
+// 执行按国家区号拆分操作，返回状态栏中的校验说明
+func (a *App) performCountrySplit(ctx context.Context, outputDir string) (string, error) {
+	subParts, subLines, err := a.countrySplitSubOptions()
 	if err != nil {
-		return fmt.Errorf("打开文件失败: %v", err)
-	}
-	defer file.Close()
-
-	// 用于存储每个国家的手机号
-	countryPhones := make(map[string][]string)
-
-	scanner := bufio.NewScanner(file)
-	// 设置更大的缓冲区以处理长行，避免 "token too long" 错误
-	buf := make([]byte, 0, 128*1024) // 128KB初始缓冲区
-	scanner.Buffer(buf, 2*1024*1024) // 2MB最大行长度
-
-	totalLines := 0
-	processedLines := 0
-
-	// 第一遍：计算总行数
-	a.countrySplitStatus.SetText("🔄 正在计算文件行数...")
-	for scanner.Scan() {
-		totalLines++
+		return "", err
 	}
-
-	// 重新打开文件进行处理
-	file.Close()
-	file, err = os.Open(a.countrySplitFile)
+	result, err := engine.CountrySplit(ctx, engine.CountrySplitOptions{
+		Input:       a.countrySplitFile,
+		OutputDir:   outputDir,
+		Dedup:       a.countrySplitDedup.Checked,
+		Mode:        a.countrySplitSelectedMode(),
+		ByCity:      a.countrySplitByCity.Checked,
+		Types:       selectedTypeMode(a.countrySplitTypes),
+		Normalize:   a.countrySplitNormalize.options(),
+		NANPRegions: a.countrySplitRegions.Checked,
+		Rejects:     a.countrySplitRejects.Checked,
+		SubParts:    subParts,
+		SubLines:    subLines,
+	}, widgetReporter{a.countrySplitProgress, a.countrySplitStatus})
 	if err != nil {
-		return fmt.Errorf("重新打开文件失败: %v", err)
-	}
-	defer file.Close()
-
-	scanner = bufio.NewScanner(file)
-	scanner.Buffer(buf, 2*1024*1024)
-
-	a.countrySplitStatus.SetText("🔄 正在识别国家区号...")
-
-	// 第二遍：按国家分类手机号
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		processedLines++
-
-		if line != "" {
-			// 识别国家
-			country := identifyCountry(line)
-
-			// 添加到对应国家的列表中
-			if countryPhones[country] == nil {
-				countryPhones[country] = make([]string, 0)
-			}
-			countryPhones[country] = append(countryPhones[country], line)
-		}
-
-		// 更新进度
-		if processedLines%10000 == 0 {
-			progress := float64(processedLines) / float64(totalLines) * 0.7 // 70%用于分类
-			a.countrySplitProgress.SetValue(progress)
-		}
+		return "", err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
-	}
-
-	a.countrySplitProgress.SetValue(0.7)
-	a.countrySplitStatus.SetText("🔄 正在生成国家文件...")
-
-	// 第三遍：为每个国家创建文件
-	countryCount := len(countryPhones)
-	currentCountry := 0
-
-	for country, phones := range countryPhones {
-		if len(phones) == 0 {
-			continue
-		}
-
-		// 创建国家文件
-		fileName := filepath.Join(outputDir, fmt.Sprintf("%s.txt", country))
-		outputFile, err := os.Create(fileName)
-		if err != nil {
-			return fmt.Errorf("创建国家文件 %s 失败: %v", fileName, err)
-		}
-
-		writer := bufio.NewWriter(outputFile)
-
-		// 写入该国家的所有手机号
-		for _, phone := range phones {
-			_, err := writer.WriteString(phone + "\n")
-			if err != nil {
-				writer.Flush()
-				outputFile.Close()
-				return fmt.Errorf("写入文件 %s 失败: %v", fileName, err)
-			}
-		}
-
-		writer.Flush()
-		outputFile.Close()
-
-		currentCountry++
-		progress := 0.7 + float64(currentCountry)/float64(countryCount)*0.3 // 剩余30%用于写入文件
-		a.countrySplitProgress.SetValue(progress)
-
-		fmt.Printf("✅ 生成文件: %s (%d个手机号)\n", fileName, len(phones))
-	}
+	a.countrySplitResults.Add(newCountrySummaryView(result))
 
 	// 输出统计信息
 	fmt.Printf("✅ 按国家区号拆分完成:\n")
-	for country, phones := range countryPhones {
-		if len(phones) > 0 {
-			fmt.Printf("   %s: %d个手机号\n", country, len(phones))
-		}
+	for _, country := range result.Countries {
+		fmt.Printf("   %s: %d个手机号\n", country.Name, country.Count)
 	}
 
-	return nil
+	return rejectsNote(a.countrySplitRejects.Checked, result.Rejected, result.RejectsFile), nil
 }
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+)
+
+// 同时打开的输出文件数上限，超过时关闭最久未写入的文件，避免超出系统文件句柄限制
+const maxOpenBuckets = 64
+
+// 每个输出文件的写缓冲大小，缓冲满了才写入文件
+const bucketBufferSize = 32 * 1024
+
+// 按路径把行写入多个输出文件（如每个国家一个文件），边读边写，不在内存中保留全部号码。
+// 每个文件有独立的写缓冲；打开的文件句柄按最近使用（LRU）保留，
+// 被关闭的文件下次写入时以追加方式重新打开。
+type bucketWriter struct {
+	outputs *outputTracker
+	maxOpen int
+	buckets map[string]*bucketFile
+	open    *list.List // 已打开的文件，最近写入的在前
+}
+
+type bucketFile struct {
+	path    string
+	buf     []byte
+	file    *os.File
+	created bool          // 已创建过（之后重新打开时追加，不再清空）
+	elem    *list.Element // 在 open 中的位置，未打开时为 nil
+}
+
+func newBucketWriter(outputs *outputTracker, maxOpen int) *bucketWriter {
+	if maxOpen <= 0 {
+		maxOpen = maxOpenBuckets
+	}
+	return &bucketWriter{
+		outputs: outputs,
+		maxOpen: maxOpen,
+		buckets: make(map[string]*bucketFile),
+		open:    list.New(),
+	}
+}
+
+// 写入一行到指定文件，文件在第一次写入时创建
+func (w *bucketWriter) write(path, line string) error {
+	b := w.buckets[path]
+	if b == nil {
+		b = &bucketFile{path: path, buf: make([]byte, 0, bucketBufferSize)}
+		w.buckets[path] = b
+	}
+	if len(b.buf)+len(line)+1 > bucketBufferSize && len(b.buf) > 0 {
+		if err := w.flush(b); err != nil {
+			return err
+		}
+	}
+	b.buf = append(b.buf, line...)
+	b.buf = append(b.buf, '\n')
+	return nil
+}
+
+// 把缓冲写入文件，必要时打开文件并关闭最久未使用的文件
+func (w *bucketWriter) flush(b *bucketFile) error {
+	if len(b.buf) == 0 && b.created {
+		return nil
+	}
+	if b.file == nil {
+		if err := w.openFile(b); err != nil {
+			return fmt.Errorf("创建文件 %s 失败: %v", b.path, err)
+		}
+	} else {
+		w.open.MoveToFront(b.elem)
+	}
+	if _, err := b.file.Write(b.buf); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", b.path, err)
+	}
+	b.buf = b.buf[:0]
+	return nil
+}
+
+func (w *bucketWriter) openFile(b *bucketFile) error {
+	for w.open.Len() >= w.maxOpen {
+		oldest := w.open.Back().Value.(*bucketFile)
+		if err := w.closeFile(oldest); err != nil {
+			return err
+		}
+	}
+
+	var file *os.File
+	var err error
+	if b.created {
+		file, err = os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND, 0)
+	} else {
+		file, err = w.outputs.create(b.path)
+	}
+	if err != nil {
+		return err
+	}
+	b.file, b.created = file, true
+	b.elem = w.open.PushFront(b)
+	return nil
+}
+
+func (w *bucketWriter) closeFile(b *bucketFile) error {
+	w.open.Remove(b.elem)
+	b.elem = nil
+	err := b.file.Close()
+	b.file = nil
+	if err != nil {
+		return fmt.Errorf("关闭文件 %s 失败: %v", b.path, err)
+	}
+	return nil
+}
+
+// 写出所有缓冲并关闭全部文件，返回遇到的第一个错误
+func (w *bucketWriter) close() error {
+	var firstErr error
+	for _, b := range w.buckets {
+		if err := w.flush(b); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if b.file != nil {
+			if err := w.closeFile(b); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// 放弃未写出的缓冲并关闭全部文件（出错或取消时使用，之后由 outputTracker 删除文件）
+func (w *bucketWriter) abort() {
+	for _, b := range w.buckets {
+		b.buf = nil
+		if b.file != nil {
+			w.closeFile(b)
+		}
+	}
+}
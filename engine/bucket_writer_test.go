@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 打开的文件数超过上限时，被关闭的文件重新打开后追加写入，内容和顺序不变
+func TestBucketWriterReopen(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets int
+		maxOpen int
+		lines   int
+	}{
+		{"不超过上限", 3, 4, 200},
+		{"超过上限", 5, 2, 200},
+		{"只能打开一个", 4, 1, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outputs := &outputTracker{}
+			w := newBucketWriter(outputs, tt.maxOpen)
+			want := make([][]string, tt.buckets)
+			// 每行 1KB，每个文件写满多次缓冲，交替写入各个文件
+			pad := strings.Repeat("x", 1000)
+			for i := 0; i < tt.lines; i++ {
+				for b := 0; b < tt.buckets; b++ {
+					line := fmt.Sprintf("%d-%d-%s", b, i, pad)
+					if err := w.write(filepath.Join(dir, fmt.Sprintf("%d.txt", b)), line); err != nil {
+						t.Fatalf("写入失败: %v", err)
+					}
+					want[b] = append(want[b], line)
+				}
+				if w.open.Len() > tt.maxOpen {
+					t.Fatalf("同时打开 %d 个文件，超过上限 %d", w.open.Len(), tt.maxOpen)
+				}
+			}
+			if err := w.close(); err != nil {
+				t.Fatalf("关闭失败: %v", err)
+			}
+			for b := range want {
+				got := readTestLines(t, filepath.Join(dir, fmt.Sprintf("%d.txt", b)))
+				if strings.Join(got, "\n") != strings.Join(want[b], "\n") {
+					t.Errorf("文件 %d.txt 有 %d 行，内容与写入的不同，期望 %d 行", b, len(got), len(want[b]))
+				}
+			}
+		})
+	}
+}
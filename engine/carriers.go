@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 中国大陆手机号段对应的运营商，每行：号段,运营商（号段为 3 位或 4 位）
+//
+//go:embed china_segments.csv
+var chinaSegmentsCSV []byte
+
+// UnknownCarrier 不是大陆手机号或号段不在号段表中时使用的运营商名称
+const UnknownCarrier = "未知运营商"
+
+var (
+	segmentOnce sync.Once
+	segments    map[string]string // 号段 -> 运营商
+)
+
+func loadChinaSegments() map[string]string {
+	segmentOnce.Do(func() {
+		records, err := csv.NewReader(bytes.NewReader(chinaSegmentsCSV)).ReadAll()
+		if err != nil {
+			panic(fmt.Sprintf("内置号段表格式错误: %v", err))
+		}
+		segments = make(map[string]string, len(records))
+		for _, record := range records[1:] { // 跳过表头
+			segments[record[0]] = record[1]
+		}
+	})
+	return segments
+}
+
+// ChinaMobileNumber 去掉 +86、0086、86 前缀，返回 11 位大陆手机号；不是大陆手机号时返回 ok=false
+func ChinaMobileNumber(number string) (mobile string, ok bool) {
+	switch {
+	case strings.HasPrefix(number, "+86"):
+		number = number[3:]
+	case strings.HasPrefix(number, "0086"):
+		number = number[4:]
+	case len(number) == 13 && strings.HasPrefix(number, "86"):
+		number = number[2:]
+	}
+	if len(number) != 11 || number[0] != '1' || !isDigits(number) {
+		return "", false
+	}
+	return number, true
+}
+
+// IdentifyCarrier 按号段识别大陆手机号的运营商（号码可带或不带 86），
+// 返回运营商和匹配到的号段；无法识别时返回 UnknownCarrier 和空号段
+func IdentifyCarrier(number string) (carrier, segment string) {
+	mobile, ok := ChinaMobileNumber(number)
+	if !ok {
+		return UnknownCarrier, ""
+	}
+	table := loadChinaSegments()
+	for _, n := range []int{4, 3} { // 4 位号段优先
+		if carrier, ok := table[mobile[:n]]; ok {
+			return carrier, mobile[:n]
+		}
+	}
+	return UnknownCarrier, ""
+}
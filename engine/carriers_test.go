@@ -0,0 +1,62 @@
+package engine
+
+import "testing"
+
+func TestChinaMobileNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		mobile string
+		ok     bool
+	}{
+		{"13800138000", "13800138000", true},
+		{"8613800138000", "13800138000", true},
+		{"+8613800138000", "13800138000", true},
+		{"008613800138000", "13800138000", true},
+		{"1380013800", "", false},            // 10 位
+		{"861380013800", "", false},          // 去掉 86 后 10 位
+		{"23800138000", "", false},           // 不以 1 开头
+		{"075512345678", "", false},          // 固话
+		{"12125551234", "12125551234", true}, // 11 位且以 1 开头，由号段表判断是否为大陆号码
+		{"138-0013-8000", "", false},         // 需要先规范化
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			mobile, ok := ChinaMobileNumber(tt.number)
+			if mobile != tt.mobile || ok != tt.ok {
+				t.Errorf("ChinaMobileNumber(%q) = %q, %v，期望 %q, %v", tt.number, mobile, ok, tt.mobile, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIdentifyCarrier(t *testing.T) {
+	tests := []struct {
+		number  string
+		carrier string
+		segment string
+	}{
+		{"13800138000", "中国移动", "138"},
+		{"8618612345678", "中国联通", "186"},
+		{"+8618912345678", "中国电信", "189"},
+		{"19212345678", "中国广电", "192"},
+		{"17012345678", "虚拟运营商", "170"},
+		{"17112345678", "虚拟运营商", "171"},
+		{"16212345678", "虚拟运营商", "162"},
+		{"16512345678", "虚拟运营商", "165"},
+		{"16712345678", "虚拟运营商", "167"},
+		{"13412345678", "中国移动", "1341"},     // 4 位号段
+		{"13492345678", UnknownCarrier, ""}, // 1349 是卫星电话，不在号段表中
+		{"14012345678", UnknownCarrier, ""},
+		{"12125551234", UnknownCarrier, ""},
+		{"075512345678", UnknownCarrier, ""},
+		{"", UnknownCarrier, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			carrier, segment := IdentifyCarrier(tt.number)
+			if carrier != tt.carrier || segment != tt.segment {
+				t.Errorf("IdentifyCarrier(%q) = %s, %q，期望 %s, %q", tt.number, carrier, segment, tt.carrier, tt.segment)
+			}
+		})
+	}
+}
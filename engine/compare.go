@@ -0,0 +1,444 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompareOptions 文件重复比较参数
+type CompareOptions struct {
+	File1     string
+	File2     string
+	OutputDir string // 相同内容和不同内容文件的输出目录
+
+	Normalize NormalizeOptions // 号码规范化规则，按规范化后的号码比较
+	Rejects   bool             // 按各国号码长度校验每一行，不合格的行不参与比较，记录到 文件1_文件2_rejects.txt
+
+	MemoryBudget int64  // 比较内存预算（字节），估算超出时改用磁盘比较；0 使用默认值，负数始终使用内存
+	TempDir      string // 磁盘比较的临时目录，默认使用输出目录
+}
+
+// CompareResult 文件重复比较结果
+type CompareResult struct {
+	SameFile  string `json:"same_file"` // 两个文件共有的行（去重）
+	DiffFile  string `json:"diff_file"` // 只出现在其中一个文件的行
+	SameLines int    `json:"same_lines"`
+	DiffLines int    `json:"diff_lines"`
+	DiskMode  bool   `json:"disk_mode"` // 是否使用了磁盘分桶比较
+	Rejected  int    `json:"rejected"`  // 两个文件中校验不合格的行数
+
+	RejectsFile string `json:"rejects_file,omitempty"` // 不合格的行所在文件，没有时为空
+}
+
+// Compare 比较两个文件，生成相同内容和不同内容两个文件。
+// 文件较大超出内存预算时按哈希分桶在磁盘上比较，输出与内存比较完全一致。
+// 取消或失败时删除未写完的输出文件。
+func Compare(ctx context.Context, opts CompareOptions, r Reporter) (result *CompareResult, err error) {
+	r = reporterOrNop(r)
+	totalBytes := totalSize(opts.File1, opts.File2)
+	meter := newProgressMeter(r, totalBytes)
+	meter.phase(0, 0.5) // 前50%用于读取文件
+
+	// 生成输出文件名
+	baseFileName1 := strings.TrimSuffix(filepath.Base(opts.File1), filepath.Ext(opts.File1))
+	baseFileName2 := strings.TrimSuffix(filepath.Base(opts.File2), filepath.Ext(opts.File2))
+
+	result = &CompareResult{
+		SameFile: filepath.Join(opts.OutputDir, fmt.Sprintf("%s_%s_相同内容.txt", baseFileName1, baseFileName2)),
+		DiffFile: filepath.Join(opts.OutputDir, fmt.Sprintf("%s_%s_不同内容.txt", baseFileName1, baseFileName2)),
+	}
+
+	outputs := &outputTracker{}
+	defer func() {
+		if err != nil {
+			outputs.removeAll()
+		}
+	}()
+	rejects := newRejectWriter(opts.Rejects, outputs,
+		rejectsPath(filepath.Join(opts.OutputDir, fmt.Sprintf("%s_%s.txt", baseFileName1, baseFileName2))))
+	defer rejects.close()
+
+	if buckets := spillBucketCount([]string{opts.File1, opts.File2}, opts.MemoryBudget); buckets > 0 {
+		tempDir := opts.TempDir
+		if tempDir == "" {
+			tempDir = opts.OutputDir
+		}
+		result.DiskMode = true
+		if err := compareOnDisk(ctx, opts, r, meter, outputs, rejects, result, tempDir, buckets); err != nil {
+			return nil, err
+		}
+		if result.Rejected, result.RejectsFile, err = rejects.close(); err != nil {
+			return nil, err
+		}
+		meter.finish()
+		return result, nil
+	}
+
+	sameLines, diffLines, err := compareInMemory(ctx, opts, meter, rejects)
+	if err != nil {
+		return nil, err
+	}
+	if result.Rejected, result.RejectsFile, err = rejects.close(); err != nil {
+		return nil, err
+	}
+	result.SameLines = len(sameLines)
+	result.DiffLines = len(diffLines)
+
+	// 写入相同内容文件
+	if err := writeLines(ctx, outputs, result.SameFile, sameLines); err == ErrCanceled {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("写入相同内容文件失败: %v", err)
+	}
+
+	// 写入不同内容文件
+	if err := writeLines(ctx, outputs, result.DiffFile, diffLines); err == ErrCanceled {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("写入不同内容文件失败: %v", err)
+	}
+
+	meter.finish()
+	return result, nil
+}
+
+// 在内存中比较两个文件，返回相同内容（去重）和不同内容
+func compareInMemory(ctx context.Context, opts CompareOptions, meter *progressMeter, rejects *rejectWriter) (sameLines, diffLines []string, err error) {
+	// 读取第一个文件
+	file1Lines, file1Keys, err := readLines(ctx, opts.File1, opts.Normalize, meter, rejects)
+	if err == ErrCanceled {
+		return nil, nil, err
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("读取文件1失败: %v", err)
+	}
+
+	// 读取第二个文件
+	file2Lines, file2Keys, err := readLines(ctx, opts.File2, opts.Normalize, meter, rejects)
+	if err == ErrCanceled {
+		return nil, nil, err
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("读取文件2失败: %v", err)
+	}
+
+	// 使用高效的集合算法进行比较
+	file1Set := newLineSet()
+	file2Set := newLineSet()
+	sameSet := newLineSet() // 用于去重相同内容
+
+	totalLines := len(file1Lines) + len(file2Lines)
+	processedLines := 0
+	meter.phase(0.5, 1)
+
+	// 构建文件1的集合
+	for _, key := range file1Keys {
+		file1Set.add(key)
+		processedLines++
+		if processedLines%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, nil, err
+			}
+			meter.set(float64(processedLines) / float64(totalLines) * 0.3) // 30%用于构建集合
+		}
+	}
+
+	// 构建文件2的集合
+	for _, key := range file2Keys {
+		file2Set.add(key)
+		processedLines++
+		if processedLines%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, nil, err
+			}
+			meter.set(float64(processedLines) / float64(totalLines) * 0.3)
+		}
+	}
+
+	// 找出相同和不同的内容
+	// 检查文件1中的每一行
+	processedLines = 0
+	for i, line := range file1Lines {
+		key := file1Keys[i]
+		if file2Set.has(key) {
+			// 相同内容（使用集合去重，O(1)复杂度）
+			if sameSet.add(key) {
+				sameLines = append(sameLines, opts.Normalize.Output(line, key))
+			}
+		} else {
+			// 文件1独有的内容
+			diffLines = append(diffLines, opts.Normalize.Output(line, key))
+		}
+
+		processedLines++
+		if processedLines%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, nil, err
+			}
+			meter.set(0.3 + float64(processedLines)/float64(len(file1Lines))*0.35) // 30%-65%
+		}
+	}
+
+	// 检查文件2中独有的内容
+	processedLines = 0
+	for i, line := range file2Lines {
+		if key := file2Keys[i]; !file1Set.has(key) {
+			diffLines = append(diffLines, opts.Normalize.Output(line, key))
+		}
+
+		processedLines++
+		if processedLines%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, nil, err
+			}
+			meter.set(0.65 + float64(processedLines)/float64(len(file2Lines))*0.25) // 65%-90%
+		}
+	}
+
+	return sameLines, diffLines, nil
+}
+
+// 读取文件所有非空行（去除首尾空白）及其规范化后的号码，未启用规范化时两者相同。
+// 校验不合格的行记录到 rejects，不返回。
+func readLines(ctx context.Context, filePath string, norm NormalizeOptions, meter *progressMeter, rejects *rejectWriter) (lines, keys []string, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	scanner := newScanner(meter.track(file))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		meter.line()
+		if lineNum%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		key := norm.Key(line)
+		if key == "" { // 跳过空行
+			continue
+		}
+		if rejected, err := rejects.check(filePath, lineNum, line, key); err != nil {
+			return nil, nil, err
+		} else if rejected {
+			continue
+		}
+		lines = append(lines, line)
+		if norm.Enabled() {
+			keys = append(keys, key)
+		}
+	}
+	if !norm.Enabled() {
+		keys = lines
+	}
+
+	return lines, keys, scanner.Err()
+}
+
+// 磁盘比较：两个文件按相同的哈希分桶，逐桶在内存中比较，
+// 再按原始行号归并各桶结果，输出顺序与内存比较完全一致。
+func compareOnDisk(ctx context.Context, opts CompareOptions, r Reporter, meter *progressMeter,
+	outputs *outputTracker, rejects *rejectWriter, result *CompareResult, tempDir string, buckets int) error {
+	dir, err := makeSpillDir(tempDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// 按行哈希把两个文件分别写入桶文件
+	buckets1, err := spillLines(ctx, opts.File1, opts.Normalize, dir, "a", buckets, meter, rejects)
+	if err == ErrCanceled {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("读取文件1失败: %v", err)
+	}
+	buckets2, err := spillLines(ctx, opts.File2, opts.Normalize, dir, "b", buckets, meter, rejects)
+	if err == ErrCanceled {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("读取文件2失败: %v", err)
+	}
+
+	// 逐桶比较
+	r.Status("🔄 正在分桶比较...")
+	meter.phase(0.5, 0.8)
+	var samePaths, diff1Paths, diff2Paths []string
+	for i := range buckets1 {
+		if err := checkCanceled(ctx); err != nil {
+			return err
+		}
+		meter.set(float64(i) / float64(len(buckets1)))
+
+		samePath := filepath.Join(dir, fmt.Sprintf("same_%d", i))
+		diff1Path := filepath.Join(dir, fmt.Sprintf("diff_a_%d", i))
+		diff2Path := filepath.Join(dir, fmt.Sprintf("diff_b_%d", i))
+		if err := compareBucket(opts.Normalize, buckets1[i], buckets2[i], samePath, diff1Path, diff2Path); err != nil {
+			return err
+		}
+		os.Remove(buckets1[i])
+		os.Remove(buckets2[i])
+		samePaths = append(samePaths, samePath)
+		diff1Paths = append(diff1Paths, diff1Path)
+		diff2Paths = append(diff2Paths, diff2Path)
+	}
+
+	// 按行号归并各桶结果
+	r.Status("🔄 正在归并比较结果...")
+	meter.phase(0.8, 1)
+
+	// 写入相同内容文件
+	if result.SameLines, err = writeSpillMerged(ctx, outputs, result.SameFile, samePaths); err == ErrCanceled {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("写入相同内容文件失败: %v", err)
+	}
+	meter.set(0.5)
+
+	// 写入不同内容文件：先文件1独有，再文件2独有
+	if result.DiffLines, err = writeSpillMerged(ctx, outputs, result.DiffFile, diff1Paths, diff2Paths); err == ErrCanceled {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("写入不同内容文件失败: %v", err)
+	}
+	return nil
+}
+
+// 读取文件所有非空行（去除首尾空白），按规范化后号码的哈希写入桶文件，返回桶文件路径。
+// 校验不合格的行记录到 rejects，不写入桶文件。
+func spillLines(ctx context.Context, filePath string, norm NormalizeOptions, dir, prefix string, count int, meter *progressMeter, rejects *rejectWriter) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	b, err := newSpillBuckets(dir, prefix, count)
+	if err != nil {
+		return nil, err
+	}
+	defer b.close()
+
+	var seq uint64
+	scanner := newScanner(meter.track(file))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		meter.line()
+		if lineNum%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		key := norm.Key(line)
+		if key == "" { // 跳过空行
+			continue
+		}
+		if rejected, err := rejects.check(filePath, lineNum, line, key); err != nil {
+			return nil, err
+		} else if rejected {
+			continue
+		}
+		seq++
+		if err := b.add(key, spillRecord{seq: seq, line: line}); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return b.close()
+}
+
+// 比较两个文件的同一个桶，相同内容（去重）、文件1独有、文件2独有按输出格式分别写入临时文件
+func compareBucket(norm NormalizeOptions, path1, path2, samePath, diff1Path, diff2Path string) (err error) {
+	var records1, records2 []spillRecord
+	var keys1, keys2 []string
+	file1Set := newLineSet()
+	file2Set := newLineSet()
+	if err := readSpillFile(path1, func(rec spillRecord) error {
+		key := norm.Key(rec.line)
+		records1 = append(records1, rec)
+		keys1 = append(keys1, key)
+		file1Set.add(key)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := readSpillFile(path2, func(rec spillRecord) error {
+		key := norm.Key(rec.line)
+		records2 = append(records2, rec)
+		keys2 = append(keys2, key)
+		file2Set.add(key)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var writers []*spillWriter
+	defer func() {
+		for _, w := range writers {
+			if closeErr := w.close(); err == nil {
+				err = closeErr
+			}
+		}
+	}()
+	for _, path := range []string{samePath, diff1Path, diff2Path} {
+		w, err := newSpillWriter(path)
+		if err != nil {
+			return err
+		}
+		writers = append(writers, w)
+	}
+	same, diff1, diff2 := writers[0], writers[1], writers[2]
+
+	sameSet := newLineSet()
+	for i, rec := range records1 {
+		key := keys1[i]
+		rec.line = norm.Output(rec.line, key)
+		if !file2Set.has(key) {
+			err = diff1.write(rec)
+		} else if sameSet.add(key) {
+			err = same.write(rec)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for i, rec := range records2 {
+		if key := keys2[i]; !file1Set.has(key) {
+			rec.line = norm.Output(rec.line, key)
+			if err := diff2.write(rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 依次归并每组临时文件并写入输出文件，返回写入的行数
+func writeSpillMerged(ctx context.Context, outputs *outputTracker, filePath string, groups ...[]string) (int, error) {
+	file, err := outputs.create(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	written := 0
+	for _, paths := range groups {
+		err := mergeSpillFiles(ctx, paths, func(rec spillRecord) error {
+			written++
+			_, err := writer.WriteString(rec.line + "\n")
+			return err
+		})
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, writer.Flush()
+}
@@ -0,0 +1,20 @@
+package engine
+
+// UnknownCountry 无法识别区号时使用的国家名称
+const UnknownCountry = "未知国家"
+
+// CountryCode 国家区号映射表中的一项
+type CountryCode struct {
+	Name     string   `json:"name"`     // 国家名称
+	Prefixes []string `json:"prefixes"` // 手机号前缀列表
+}
+
+// IdentifyCountry 根据手机号前缀，按当前生效的区号表识别国家（最长前缀优先）
+func IdentifyCountry(phoneNumber string) string {
+	return activeCountryMatcher().Identify(phoneNumber)
+}
+
+// MatchCountry 按当前生效的区号表识别国家，并返回匹配到的前缀（无法识别时为空）
+func MatchCountry(phoneNumber string) (country, prefix string) {
+	return activeCountryMatcher().Match(phoneNumber)
+}
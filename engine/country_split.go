@@ -0,0 +1,385 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CountrySplitMode 区号拆分的分组方式
+type CountrySplitMode int
+
+const (
+	SplitByCountry  CountrySplitMode = iota // 按国家区号，每个国家一个文件
+	SplitByCarrier                          // 按中国大陆手机号段，每个运营商一个文件
+	SplitByLocation                         // 按中国大陆号码归属地（手机号段或固话区号），每个省份（或城市）一个文件
+)
+
+// CountrySplitModes 分组方式的显示名称，顺序与 CountrySplitMode 取值一致
+var CountrySplitModes = []string{"按国家区号", "按运营商（中国大陆号段）", "按归属地（中国大陆省市）"}
+
+// ParseCountrySplitMode 按名称解析分组方式，支持 country/carrier/location
+func ParseCountrySplitMode(name string) (CountrySplitMode, error) {
+	switch strings.ToLower(name) {
+	case "", "country":
+		return SplitByCountry, nil
+	case "carrier":
+		return SplitByCarrier, nil
+	case "location":
+		return SplitByLocation, nil
+	}
+	return SplitByCountry, fmt.Errorf("未知的拆分方式: %s", name)
+}
+
+// 统计表第一列的名称
+func (m CountrySplitMode) groupName() string {
+	switch m {
+	case SplitByCarrier:
+		return "运营商"
+	case SplitByLocation:
+		return "归属地"
+	}
+	return "国家"
+}
+
+// CountrySplitOptions 按国家区号拆分参数
+type CountrySplitOptions struct {
+	Input     string // 要拆分的文件
+	OutputDir string // 国家文件输出目录
+	Dedup     bool   // 是否去除重复号码
+
+	Mode CountrySplitMode // 分组方式，默认按国家区号
+
+	Normalize NormalizeOptions // 号码规范化规则，按规范化后的号码识别国家和去重
+	Countries []CountryCode    // 区号表，为空时使用当前生效的区号表
+
+	NANPRegions bool // 按国家拆分时，美国和加拿大的号码按州/省拆分，如 美国_加州.txt、加拿大_安大略省.txt
+	ByCity      bool // 按归属地拆分时细分到城市，如 广东_深圳.txt；否则每个省份一个文件
+
+	Types TypeMode // 按号码类型处理：只保留手机号，或每种类型分别输出（如 英国_手机.txt）
+
+	Rejects bool // 按各国号码长度校验每一行，不合格的行不写入国家文件，记录到输出目录的 源文件名_rejects.txt
+
+	// 每个国家（或运营商、归属地）的文件再拆分，输出到以国家命名的子文件夹，如 美国/美国_part1.txt；
+	// 两者只能设置一个，都为 0 时不再拆分
+	SubParts int // 每个国家平均拆分为几份
+	SubLines int // 每个国家按每份最多的行数拆分
+
+	MemoryBudget int64  // 去重内存预算（字节），估算超出时改用磁盘去重；0 使用默认值，负数始终使用内存
+	TempDir      string // 磁盘去重的临时目录，默认使用输出目录
+}
+
+// CountryCount 单个国家的拆分结果
+type CountryCount struct {
+	Name          string  `json:"name"`             // 国家名称
+	Region        string  `json:"region,omitempty"` // 州/省或城市，只在按州/省拆分北美号码或按城市拆分时有
+	Type          string  `json:"type,omitempty"`   // 号码类型，只在按类型分别输出时有
+	File          string  `json:"file"`             // 输出文件路径，再拆分时为该国家的子文件夹
+	Count         int     `json:"count"`            // 写入的号码数量
+	Share         float64 `json:"share"`            // 占全部写入号码的比例，0~1
+	Duplicates    int     `json:"duplicates"`       // 去重丢弃的号码数
+	InvalidLength int     `json:"invalid_length"`   // 不符合号码长度规则的号码数（未开启校验时仍写入文件）
+
+	MatchedPrefixes map[string]int `json:"matched_prefixes,omitempty"` // 匹配到的区号前缀（或号段）及号码数量
+
+	Parts []string `json:"parts,omitempty"` // 再拆分生成的分片文件
+}
+
+// Label 分组名称，按州/省、城市或号码类型拆分时为 国家_州省、省份_城市、国家_手机 等（与输出文件名相同）
+func (c CountryCount) Label() string {
+	label := c.Name
+	if c.Region != "" {
+		label += "_" + c.Region
+	}
+	if c.Type != "" {
+		label += "_" + c.Type
+	}
+	return label
+}
+
+// CountrySplitResult 按国家区号拆分结果
+type CountrySplitResult struct {
+	GroupBy       string         `json:"group_by"`               // 分组方式：国家或运营商
+	LinesRead     int            `json:"lines_read"`             // 读取的非空行数
+	LinesWritten  int            `json:"lines_written"`          // 写入国家文件的行数
+	Duplicates    int            `json:"duplicates"`             // 去重丢弃的行数
+	InvalidLength int            `json:"invalid_length"`         // 不符合号码长度规则、仍写入文件的号码数
+	NonMobile     int            `json:"non_mobile"`             // 只保留手机号时丢弃的号码数
+	UnknownType   int            `json:"unknown_type"`           // 只保留手机号时无法判断类型、仍然写入的号码数
+	Rejected      int            `json:"rejected"`               // 校验不合格、写入 _rejects.txt 的行数
+	RejectsFile   string         `json:"rejects_file,omitempty"` // 不合格的行所在文件，没有时为空
+	DiskDedup     bool           `json:"disk_dedup"`             // 是否使用了磁盘去重
+	SummaryCSV    string         `json:"summary_csv"`            // 统计表 summary.csv 的路径
+	SummaryJSON   string         `json:"summary_json"`           // 统计表 summary.json 的路径
+	Countries     []CountryCount `json:"countries"`              // 按号码数量从多到少排列
+}
+
+// CountrySplit 识别每个号码的国家区号，按国家生成独立文件（国家名.txt），
+// 或按大陆手机号段的运营商（中国移动.txt 等）、归属地（广东.txt、广东_深圳.txt）生成文件，
+// 并在输出目录生成统计表 summary.csv 和 summary.json。设置 SubParts 或 SubLines 时，
+// 每个国家的文件再拆分到以国家命名的子文件夹（美国/美国_part1.txt）。
+// 不去重时边读边写入对应国家的文件，内存占用与文件大小无关。
+// 取消或失败时删除本次已生成的国家文件。
+func CountrySplit(ctx context.Context, opts CountrySplitOptions, r Reporter) (result *CountrySplitResult, err error) {
+	r = reporterOrNop(r)
+	if opts.SubParts < 0 || opts.SubLines < 0 || opts.SubParts > 0 && opts.SubLines > 0 {
+		return nil, fmt.Errorf("每个国家只能按份数或按每份行数其中一种方式再拆分")
+	}
+
+	file, err := os.Open(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	matcher := activeCountryMatcher()
+	if len(opts.Countries) > 0 {
+		matcher = NewCountryMatcher(opts.Countries)
+	}
+
+	outputs := &outputTracker{}
+	buckets := newBucketWriter(outputs, maxOpenBuckets)
+	defer func() {
+		if err != nil {
+			buckets.abort()
+			outputs.removeAll()
+		}
+	}()
+	rejects := newRejectWriter(opts.Rejects, outputs, filepath.Join(opts.OutputDir, rejectsPath(filepath.Base(opts.Input))))
+	defer rejects.close()
+
+	result = &CountrySplitResult{GroupBy: opts.Mode.groupName()}
+	counts := make(map[string]*CountryCount) // 按输出文件名（国家名或 国家_州省）
+	read := make(map[*CountryCount]int)      // 每个文件去重前的号码数，只用于计算各国去除的重复数
+
+	// 校验号码长度用的号码：按运营商和归属地拆分时号码不一定带 86，补上国家码后按大陆规则校验
+	validationKey := func(key string) string {
+		if opts.Mode == SplitByCountry {
+			return key
+		}
+		return chinaInternational(key)
+	}
+
+	// 号码类型：按运营商和归属地拆分时号码不一定带 86，按大陆号码判断
+	numberType := func(key string) NumberType {
+		if opts.Mode == SplitByCountry {
+			return ClassifyNumber(key)
+		}
+		if mobile, ok := ChinaMobileNumber(key); ok {
+			return ClassifyNumber("86" + mobile)
+		}
+		if national, ok := chinaFixedLine(key); ok {
+			return ClassifyNumber("86" + national[1:])
+		}
+		return TypeUnknown
+	}
+
+	// 识别号码所属国家（按州/省拆分时细分到州/省）或运营商，返回对应的统计项；
+	// 只保留手机号时，确定不是手机号的返回 nil，无法判断类型的照常返回
+	classify := func(key string) (*CountryCount, string) {
+		var typeName string
+		if opts.Types != TypeAll {
+			t := numberType(key)
+			if opts.Types == TypeMobileOnly && t != TypeUnknown && !t.MayBeMobile() {
+				return nil, ""
+			}
+			if opts.Types == TypeSplit {
+				typeName = t.String()
+			}
+		}
+
+		var country, prefix, region string
+		switch opts.Mode {
+		case SplitByCarrier:
+			country, prefix = IdentifyCarrier(key)
+		case SplitByLocation:
+			country = UnknownLocation
+			if loc, segment, ok := IdentifyLocation(key); ok {
+				country, prefix = loc.Province, segment
+				if opts.ByCity {
+					region = loc.City
+				}
+			}
+		default:
+			country, prefix = matcher.Match(key)
+			if opts.NANPRegions {
+				if regionCountry, r, ok := NANPRegion(key); ok && regionCountry == country {
+					region = r
+				}
+			}
+		}
+		group := CountryCount{Name: country, Region: region, Type: typeName}
+		name := group.Label()
+		count := counts[name]
+		if count == nil {
+			group.File = filepath.Join(opts.OutputDir, fmt.Sprintf("%s.txt", name))
+			count = &group
+			counts[name] = count
+		}
+		return count, prefix
+	}
+
+	emit := func(line, key string, count *CountryCount, prefix string) error {
+		if err := buckets.write(count.File, opts.Normalize.Output(line, key)); err != nil {
+			return err
+		}
+		count.Count++
+		result.LinesWritten++
+		if opts.Types == TypeMobileOnly && numberType(key) == TypeUnknown {
+			result.UnknownType++
+		}
+		if ValidateNumber(validationKey(key)) != "" {
+			count.InvalidLength++
+			result.InvalidLength++
+		}
+		if prefix != "" {
+			if count.MatchedPrefixes == nil {
+				count.MatchedPrefixes = make(map[string]int)
+			}
+			count.MatchedPrefixes[prefix]++
+		}
+		return nil
+	}
+
+	var dedup lineDeduper
+	if opts.Dedup {
+		tempDir := opts.TempDir
+		if tempDir == "" {
+			tempDir = opts.OutputDir
+		}
+		if dedup, err = newLineDeduper([]string{opts.Input}, opts.MemoryBudget, tempDir, opts.Normalize.Key); err != nil {
+			return nil, err
+		}
+		defer dedup.close()
+		_, result.DiskDedup = dedup.(*spillDeduper)
+	}
+	// 去重后输出的行重新识别国家（磁盘去重时行在归并阶段才输出）
+	writeDeduped := func(line string) error {
+		key := opts.Normalize.Key(line)
+		count, prefix := classify(key)
+		if count == nil {
+			return nil
+		}
+		return emit(line, key, count, prefix)
+	}
+
+	meter := newProgressMeter(r, totalSize(opts.Input))
+	scanner := newScanner(meter.track(file))
+	processedLines := 0
+
+	r.Status("🔄 正在按国家拆分...")
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		processedLines++
+		meter.line()
+		if processedLines%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		if key := opts.Normalize.Key(line); key != "" {
+			result.LinesRead++
+			if rejected, err := rejects.check(opts.Input, processedLines, line, validationKey(key)); err != nil {
+				return nil, err
+			} else if rejected {
+				continue
+			}
+			count, prefix := classify(key)
+			if count == nil {
+				result.NonMobile++
+				continue
+			}
+			read[count]++
+			if dedup != nil {
+				err = dedup.add(key, line, writeDeduped)
+			} else {
+				err = emit(line, key, count, prefix)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	if dedup != nil {
+		if result.DiskDedup {
+			r.Status("🔄 正在归并磁盘去重结果...")
+		}
+		if err := dedup.finish(ctx, writeDeduped); err != nil {
+			return nil, err
+		}
+	}
+	if err := buckets.close(); err != nil {
+		return nil, err
+	}
+	if result.Rejected, result.RejectsFile, err = rejects.close(); err != nil {
+		return nil, err
+	}
+	if dedup != nil {
+		result.Duplicates = result.LinesRead - result.Rejected - result.NonMobile - result.LinesWritten
+	}
+
+	for _, count := range counts {
+		count.Duplicates = read[count] - count.Count
+		if result.LinesWritten > 0 {
+			count.Share = float64(count.Count) / float64(result.LinesWritten)
+		}
+		result.Countries = append(result.Countries, *count)
+	}
+	sort.Slice(result.Countries, func(i, j int) bool {
+		if result.Countries[i].Count != result.Countries[j].Count {
+			return result.Countries[i].Count > result.Countries[j].Count
+		}
+		return result.Countries[i].Label() < result.Countries[j].Label()
+	})
+
+	if opts.SubParts > 0 || opts.SubLines > 0 {
+		if err := splitCountryFiles(ctx, opts, r, outputs, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeCountrySummary(outputs, opts.OutputDir, result); err != nil {
+		return nil, err
+	}
+
+	meter.finish()
+	return result, nil
+}
+
+// 把每个国家的文件再拆分到以国家命名的子文件夹，拆分后删除原来的国家文件。
+// 号码已经过去重、校验和规范化，再拆分时按行原样写入。
+func splitCountryFiles(ctx context.Context, opts CountrySplitOptions, r Reporter, outputs *outputTracker, result *CountrySplitResult) error {
+	sub := SplitOptions{Mode: SplitByParts, Parts: opts.SubParts}
+	if opts.SubLines > 0 {
+		sub = SplitOptions{Mode: SplitByLines, LinesPerPart: opts.SubLines}
+	}
+	for i := range result.Countries {
+		country := &result.Countries[i]
+		r.Status(fmt.Sprintf("🔄 正在拆分 %s（%d/%d）...", country.Label(), i+1, len(result.Countries)))
+		dir := filepath.Join(opts.OutputDir, country.Label())
+		if err := outputs.mkdir(dir); err != nil {
+			return fmt.Errorf("创建文件夹 %s 失败: %v", dir, err)
+		}
+		sub.Input, sub.OutputDir = country.File, dir
+		parts, err := Split(ctx, sub, nil)
+		if err != nil {
+			return err
+		}
+		outputs.add(parts.Outputs...)
+		if err := os.Remove(country.File); err != nil {
+			return fmt.Errorf("删除文件 %s 失败: %v", country.File, err)
+		}
+		country.File, country.Parts = dir, parts.Outputs
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 国家数多于同时打开的文件数上限时，每个国家文件仍按输入顺序包含全部号码
+func TestCountrySplitManyCountries(t *testing.T) {
+	dir := t.TempDir()
+	var codes []CountryCode
+	for c := 0; c < maxOpenBuckets*2; c++ {
+		codes = append(codes, CountryCode{Name: fmt.Sprintf("国家%03d", c), Prefixes: []string{fmt.Sprintf("9%03d", c)}})
+	}
+	var lines []string
+	want := make(map[string][]string)
+	for i := 0; i < 20000; i++ {
+		c := i * 7 % len(codes)
+		line := fmt.Sprintf("9%03d%08d", c, i)
+		lines = append(lines, line)
+		want[codes[c].Name] = append(want[codes[c].Name], line)
+	}
+	lines = append(lines, "", "备注")
+	want[UnknownCountry] = []string{"备注"}
+	input := writeTestFile(t, dir, "in.txt", lines)
+
+	result, err := CountrySplit(context.Background(), CountrySplitOptions{
+		Input:     input,
+		OutputDir: dir,
+		Countries: codes,
+	}, nil)
+	if err != nil {
+		t.Fatalf("拆分失败: %v", err)
+	}
+	if result.LinesRead != 20001 || result.LinesWritten != 20001 {
+		t.Errorf("读取/写入 = %d/%d，期望 20001/20001", result.LinesRead, result.LinesWritten)
+	}
+	if len(result.Countries) != len(want) {
+		t.Fatalf("生成 %d 个国家，期望 %d 个", len(result.Countries), len(want))
+	}
+	for _, country := range result.Countries {
+		if country.File != filepath.Join(dir, country.Name+".txt") {
+			t.Errorf("%s 的文件为 %s", country.Name, country.File)
+		}
+		got := readTestLines(t, country.File)
+		if strings.Join(got, "\n") != strings.Join(want[country.Name], "\n") {
+			t.Errorf("%s.txt 有 %d 行，与输入中该国家的号码不同，期望 %d 行", country.Name, len(got), len(want[country.Name]))
+		}
+		if country.Count != len(want[country.Name]) {
+			t.Errorf("%s 的号码数 = %d，期望 %d", country.Name, country.Count, len(want[country.Name]))
+		}
+	}
+}
+
+// 每个分组的统计项（只比较数量，不比较文件路径和占比）
+type countryStat struct {
+	count, duplicates, invalid int
+	prefixes                   map[string]int
+}
+
+// 统计表中的号码数、去除重复、长度异常和匹配前缀，以及 summary.csv 的合计行
+func TestCountrySplitSummary(t *testing.T) {
+	codes := []CountryCode{{"中国", []string{"86"}}, {"英国", []string{"44"}}}
+	tests := []struct {
+		name  string
+		opts  CountrySplitOptions
+		lines []string
+		want  map[string]countryStat
+		total string // summary.csv 的合计行
+	}{
+		{
+			"按国家",
+			CountrySplitOptions{Countries: codes, Dedup: true, Normalize: DefaultNormalizeOptions()},
+			[]string{"8613800138000", "+86 138 0013 8000", "447700900123", "4477009001", "8613800138", "12125551234"},
+			map[string]countryStat{
+				"中国":           {2, 1, 1, map[string]int{"86": 2}},
+				"英国":           {2, 0, 1, map[string]int{"44": 2}},
+				UnknownCountry: {1, 0, 0, nil},
+			},
+			"合计,,5,100.00%,1,2",
+		},
+		{
+			"按运营商，不带 86 的号码按大陆规则校验",
+			CountrySplitOptions{Mode: SplitByCarrier, Dedup: true, Normalize: DefaultNormalizeOptions()},
+			[]string{"13800138000", "138 0013 8000", "8613900139000", "18912345678", "1380013800", "12125551234"},
+			map[string]countryStat{
+				"中国移动":         {2, 1, 0, map[string]int{"138": 1, "139": 1}},
+				"中国电信":         {1, 0, 0, map[string]int{"189": 1}},
+				UnknownCarrier: {2, 0, 1, nil},
+			},
+			"合计,,5,100.00%,1,1",
+		},
+		{
+			"按归属地，手机号使用内置号段，固话按去掉 0 的区号校验",
+			CountrySplitOptions{Mode: SplitByLocation, ByCity: true},
+			[]string{"075512345678", "0755123456", "02012345678", "+86 10 1234 5678", "13800138000"},
+			map[string]countryStat{
+				"广东_深圳": {2, 0, 1, map[string]int{"0755": 2}},
+				"广东_广州": {1, 0, 0, map[string]int{"020": 1}},
+				"北京_北京": {2, 0, 0, map[string]int{"010": 1, "138001": 1}},
+			},
+			"合计,,5,100.00%,0,1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := tt.opts
+			opts.Input = writeTestFile(t, dir, "in.txt", tt.lines)
+			opts.OutputDir = dir
+			if opts.Mode == SplitByLocation {
+				opts.Normalize = DefaultNormalizeOptions()
+			}
+			result, err := CountrySplit(context.Background(), opts, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+
+			got := make(map[string]countryStat)
+			shares := 0.0
+			for _, c := range result.Countries {
+				got[c.Label()] = countryStat{c.Count, c.Duplicates, c.InvalidLength, c.MatchedPrefixes}
+				shares += c.Share
+				if lines := readTestLines(t, c.File); len(lines) != c.Count {
+					t.Errorf("%s 的文件有 %d 行，统计为 %d", c.Label(), len(lines), c.Count)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("统计 = %v，期望 %v", got, tt.want)
+			}
+			if shares < 0.9999 || shares > 1.0001 {
+				t.Errorf("占比合计 %v，期望 1", shares)
+			}
+
+			csvLines := readTestLines(t, result.SummaryCSV)
+			if last := csvLines[len(csvLines)-1]; last != tt.total {
+				t.Errorf("summary.csv 合计行 = %q，期望 %q", last, tt.total)
+			}
+			data, err := os.ReadFile(result.SummaryJSON)
+			if err != nil {
+				t.Fatalf("读取 summary.json 失败: %v", err)
+			}
+			var summary CountrySplitResult
+			if err := json.Unmarshal(data, &summary); err != nil {
+				t.Fatalf("summary.json 格式错误: %v", err)
+			}
+			if !reflect.DeepEqual(&summary, result) {
+				t.Errorf("summary.json 与拆分结果不同")
+			}
+		})
+	}
+}
+
+// 按州/省拆分时美国和加拿大的号码细分到州/省，其他北美国家和地区仍按国家输出
+func TestCountrySplitNANPRegions(t *testing.T) {
+	codes, _ := builtinCountryCodes()
+	tests := []struct {
+		name    string
+		regions bool
+		want    map[string][]string // 文件名（不含 .txt）到号码
+	}{
+		{"按州/省", true, map[string][]string{
+			"美国_纽约州":   {"12125551234", "+1 212 555 0000"},
+			"美国_加州":    {"13105551234"},
+			"加拿大_安大略省": {"14165551234"},
+			"波多黎各":     {"17875551234"},
+			"北美免费电话":   {"18005551234"},
+			"英国":       {"442071234567"},
+		}},
+		{"按国家", false, map[string][]string{
+			"美国":     {"12125551234", "13105551234", "+1 212 555 0000"},
+			"加拿大":    {"14165551234"},
+			"波多黎各":   {"17875551234"},
+			"北美免费电话": {"18005551234"},
+			"英国":     {"442071234567"},
+		}},
+	}
+	lines := []string{"12125551234", "13105551234", "14165551234", "+1 212 555 0000", "17875551234", "18005551234", "442071234567"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			result, err := CountrySplit(context.Background(), CountrySplitOptions{
+				Input:       writeTestFile(t, dir, "in.txt", lines),
+				OutputDir:   dir,
+				Countries:   codes,
+				Normalize:   DefaultNormalizeOptions(),
+				NANPRegions: tt.regions,
+			}, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+			got := make(map[string][]string)
+			for _, c := range result.Countries {
+				if c.File != filepath.Join(dir, c.Label()+".txt") {
+					t.Errorf("%s 的文件为 %s", c.Label(), c.File)
+				}
+				got[c.Label()] = readTestLines(t, c.File)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("输出 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 只保留手机号时丢弃确定不是手机的号码，无法判断类型的号码照常写入并单独计数
+func TestCountrySplitMobileOnly(t *testing.T) {
+	codes := []CountryCode{{"中国", []string{"86"}}, {"香港", []string{"852"}}, {"英国", []string{"44"}}}
+	tests := []struct {
+		name    string
+		mode    CountrySplitMode
+		lines   []string
+		want    map[string][]string
+		nonMob  int
+		unknown int
+	}{
+		{"按国家", SplitByCountry,
+			[]string{"8613800138000", "8675512345678", "85221234567", "448001234567", "447700900123"},
+			map[string][]string{"中国": {"8613800138000"}, "香港": {"85221234567"}, "英国": {"447700900123"}}, 2, 1},
+		{"按运营商，固话按大陆号码判断", SplitByCarrier,
+			[]string{"13800138000", "075512345678", "8675512345678", "85221234567"},
+			map[string][]string{"中国移动": {"13800138000"}, UnknownCarrier: {"85221234567"}}, 2, 1},
+		{"按归属地", SplitByLocation,
+			[]string{"13800138000", "075512345678", "0755123"},
+			map[string][]string{"北京": {"13800138000"}, UnknownLocation: {"0755123"}}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			result, err := CountrySplit(context.Background(), CountrySplitOptions{
+				Input:     writeTestFile(t, dir, "in.txt", tt.lines),
+				OutputDir: dir,
+				Mode:      tt.mode,
+				Countries: codes,
+				Types:     TypeMobileOnly,
+			}, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+			got := make(map[string][]string)
+			for _, c := range result.Countries {
+				got[c.Label()] = readTestLines(t, c.File)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("输出 = %v，期望 %v", got, tt.want)
+			}
+			if result.NonMobile != tt.nonMob || result.UnknownType != tt.unknown {
+				t.Errorf("非手机号/无法判断类型 = %d/%d，期望 %d/%d", result.NonMobile, result.UnknownType, tt.nonMob, tt.unknown)
+			}
+		})
+	}
+}
+
+// 每个国家再拆分到以国家命名的子文件夹，原来的国家文件删除
+func TestCountrySplitSubParts(t *testing.T) {
+	codes := []CountryCode{{"英国", []string{"44"}}, {"哈萨克斯坦", []string{"77"}}}
+	lines := append(splitTestLines("44", 5), splitTestLines("77", 2)...)
+	tests := []struct {
+		name     string
+		subParts int
+		subLines int
+		want     map[string][]int // 国家 -> 每份的行数
+		wantErr  bool
+	}{
+		{"按份数", 2, 0, map[string][]int{"英国": {3, 2}, "哈萨克斯坦": {1, 1}}, false},
+		{"按每份行数", 0, 2, map[string][]int{"英国": {2, 2, 1}, "哈萨克斯坦": {2}}, false},
+		{"两种方式只能选一种", 2, 2, nil, true},
+		{"份数为负", -1, 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := writeTestFile(t, dir, "in.txt", lines)
+			outputDir := filepath.Join(dir, "out")
+			if err := os.MkdirAll(outputDir, 0755); err != nil {
+				t.Fatalf("创建输出目录失败: %v", err)
+			}
+			result, err := CountrySplit(context.Background(), CountrySplitOptions{
+				Input:     input,
+				OutputDir: outputDir,
+				Countries: codes,
+				SubParts:  tt.subParts,
+				SubLines:  tt.subLines,
+			}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望参数错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+
+			got := make(map[string][]int)
+			for _, c := range result.Countries {
+				if c.File != filepath.Join(outputDir, c.Name) {
+					t.Errorf("%s 的文件为 %s，期望子文件夹", c.Name, c.File)
+				}
+				if _, err := os.Stat(filepath.Join(outputDir, c.Name+".txt")); !os.IsNotExist(err) {
+					t.Errorf("%s.txt 没有删除", c.Name)
+				}
+				for i, part := range c.Parts {
+					if want := filepath.Join(outputDir, c.Name, fmt.Sprintf("%s_part%d.txt", c.Name, i+1)); part != want {
+						t.Errorf("分片 %s，期望 %s", part, want)
+					}
+					got[c.Name] = append(got[c.Name], len(readTestLines(t, part)))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("每份行数 = %v，期望 %v", got, tt.want)
+			}
+			if _, err := os.Stat(result.SummaryCSV); err != nil {
+				t.Errorf("没有生成统计表: %v", err)
+			}
+		})
+	}
+}
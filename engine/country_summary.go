@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// 区号拆分统计表的文件名，写在国家文件旁边
+const (
+	CountrySummaryCSV  = "summary.csv"
+	CountrySummaryJSON = "summary.json"
+)
+
+// 在输出目录写入 summary.csv 和 summary.json，并记录到 result 中
+func writeCountrySummary(outputs *outputTracker, dir string, result *CountrySplitResult) error {
+	result.SummaryCSV = filepath.Join(dir, CountrySummaryCSV)
+	result.SummaryJSON = filepath.Join(dir, CountrySummaryJSON)
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成统计表失败: %v", err)
+	}
+	if err := writeOutputFile(outputs, result.SummaryJSON, append(data, '\n')); err != nil {
+		return err
+	}
+	return writeOutputFile(outputs, result.SummaryCSV, formatCountrySummaryCSV(result))
+}
+
+// 每个国家一行，最后一行为合计
+func formatCountrySummaryCSV(result *CountrySplitResult) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff") // 带 BOM，Excel 打开时中文不乱码
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{result.GroupBy, "匹配前缀数", "号码数", "占比", "去除重复", "长度异常"})
+	for _, c := range result.Countries {
+		writer.Write([]string{
+			c.Label(),
+			strconv.Itoa(len(c.MatchedPrefixes)),
+			strconv.Itoa(c.Count),
+			FormatShare(c.Share),
+			strconv.Itoa(c.Duplicates),
+			strconv.Itoa(c.InvalidLength),
+		})
+	}
+	total := FormatShare(0)
+	if result.LinesWritten > 0 {
+		total = FormatShare(1)
+	}
+	writer.Write([]string{
+		"合计",
+		"",
+		strconv.Itoa(result.LinesWritten),
+		total,
+		strconv.Itoa(result.Duplicates),
+		strconv.Itoa(result.InvalidLength),
+	})
+	writer.Flush()
+	return buf.Bytes()
+}
+
+// FormatShare 将 0~1 的比例格式化为百分比，如 12.34%
+func FormatShare(share float64) string {
+	return strconv.FormatFloat(share*100, 'f', 2, 64) + "%"
+}
+
+// 创建输出文件并一次写入全部内容
+func writeOutputFile(outputs *outputTracker, path string, data []byte) error {
+	file, err := outputs.create(path)
+	if err != nil {
+		return fmt.Errorf("创建文件 %s 失败: %v", path, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", path, err)
+	}
+	return nil
+}
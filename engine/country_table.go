@@ -0,0 +1,343 @@
+package engine
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 内置的默认区号表，规则文件不存在时使用
+//
+//go:embed countries.json
+var defaultCountriesJSON []byte
+
+// CountryRulesFile 放在程序旁边的区号规则文件名，也支持同名的 .csv 文件
+const CountryRulesFile = "country_codes.json"
+
+var (
+	countryMu      sync.RWMutex
+	countryCodes   []CountryCode   // 当前生效的区号表，为空时使用内置默认表
+	countryMatcher *CountryMatcher // 当前区号表对应的匹配器
+
+	defaultOnce    sync.Once
+	defaultCodes   []CountryCode
+	defaultMatcher *CountryMatcher
+)
+
+// 解析一次内置默认表
+func builtinCountryCodes() ([]CountryCode, *CountryMatcher) {
+	defaultOnce.Do(func() {
+		codes, err := parseCountryJSON(defaultCountriesJSON)
+		if err != nil {
+			panic(fmt.Sprintf("内置区号表格式错误: %v", err))
+		}
+		defaultCodes = codes
+		defaultMatcher = NewCountryMatcher(codes)
+	})
+	return defaultCodes, defaultMatcher
+}
+
+// 当前生效的区号表（只读，不要修改）
+func activeCountryCodes() []CountryCode {
+	countryMu.RLock()
+	defer countryMu.RUnlock()
+	if countryCodes == nil {
+		codes, _ := builtinCountryCodes()
+		return codes
+	}
+	return countryCodes
+}
+
+// 当前生效的区号表对应的匹配器
+func activeCountryMatcher() *CountryMatcher {
+	countryMu.RLock()
+	defer countryMu.RUnlock()
+	if countryMatcher == nil {
+		_, matcher := builtinCountryCodes()
+		return matcher
+	}
+	return countryMatcher
+}
+
+// DefaultCountryCodes 返回内置的默认区号表（副本）
+func DefaultCountryCodes() []CountryCode {
+	codes, _ := builtinCountryCodes()
+	return cloneCountryCodes(codes)
+}
+
+// CountryCodes 返回当前生效的区号表（副本）
+func CountryCodes() []CountryCode {
+	return cloneCountryCodes(activeCountryCodes())
+}
+
+// SetCountryCodes 替换当前生效的区号表，之后的区号拆分使用新表；传入 nil 恢复内置默认表
+func SetCountryCodes(codes []CountryCode) {
+	var matcher *CountryMatcher
+	if codes != nil {
+		codes = cloneCountryCodes(codes)
+		matcher = NewCountryMatcher(codes)
+	}
+	countryMu.Lock()
+	countryCodes, countryMatcher = codes, matcher
+	countryMu.Unlock()
+}
+
+func cloneCountryCodes(codes []CountryCode) []CountryCode {
+	clone := make([]CountryCode, len(codes))
+	for i, c := range codes {
+		clone[i] = CountryCode{Name: c.Name, Prefixes: append([]string(nil), c.Prefixes...)}
+	}
+	return clone
+}
+
+// CountryRulesPath 返回程序旁边的区号规则文件路径。
+// 存在 country_codes.json 时使用它，否则存在 country_codes.csv 时使用 CSV，
+// 都不存在时返回 JSON 路径（保存时创建）。
+func CountryRulesPath() string {
+	dir := "."
+	if exe, err := os.Executable(); err == nil {
+		dir = filepath.Dir(exe)
+	}
+	jsonPath := filepath.Join(dir, CountryRulesFile)
+	if _, err := os.Stat(jsonPath); err == nil {
+		return jsonPath
+	}
+	csvPath := strings.TrimSuffix(jsonPath, ".json") + ".csv"
+	if _, err := os.Stat(csvPath); err == nil {
+		return csvPath
+	}
+	return jsonPath
+}
+
+// LoadCountryRules 加载程序旁边的区号规则文件并设为当前区号表。
+// 文件不存在时使用内置默认表，返回的 path 为空。
+func LoadCountryRules() (path string, err error) {
+	path = CountryRulesPath()
+	if _, statErr := os.Stat(path); statErr != nil {
+		SetCountryCodes(nil)
+		return "", nil
+	}
+	codes, err := LoadCountryCodes(path)
+	if err != nil {
+		return path, err
+	}
+	if err := checkCountryIssues(codes); err != nil {
+		return path, err
+	}
+	SetCountryCodes(codes)
+	return path, nil
+}
+
+// LoadCountryCodes 从 JSON 或 CSV 文件读取区号表（按扩展名判断格式）
+func LoadCountryCodes(path string) ([]CountryCode, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取区号规则文件失败: %v", err)
+	}
+	var codes []CountryCode
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		codes, err = parseCountryCSV(bytes.NewReader(data))
+	} else {
+		codes, err = parseCountryJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析区号规则文件 %s 失败: %v", filepath.Base(path), err)
+	}
+	return codes, nil
+}
+
+// SaveCountryCodes 将区号表写入 JSON 或 CSV 文件（按扩展名判断格式）
+func SaveCountryCodes(path string, codes []CountryCode) error {
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		data = formatCountryCSV(codes)
+	} else {
+		data = formatCountryJSON(codes)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存区号规则文件失败: %v", err)
+	}
+	return nil
+}
+
+func parseCountryJSON(data []byte) ([]CountryCode, error) {
+	var codes []CountryCode
+	if err := json.Unmarshal(data, &codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// 每个国家占一行，便于手工编辑和比较差异
+func formatCountryJSON(codes []CountryCode) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, c := range codes {
+		if c.Prefixes == nil {
+			c.Prefixes = []string{}
+		}
+		line, _ := json.Marshal(c)
+		buf.WriteString("  ")
+		buf.Write(line)
+		if i < len(codes)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes()
+}
+
+// CSV 每行一个前缀：国家,前缀。国家按第一次出现的顺序排列，可带表头
+func parseCountryCSV(r io.Reader) ([]CountryCode, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var codes []CountryCode
+	index := make(map[string]int)
+	for lineNum := 1; ; lineNum++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 0 || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(record[0]), "\ufeff")
+		if lineNum == 1 && (name == "country" || name == "国家") {
+			continue // 表头
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("第 %d 行缺少前缀列", lineNum)
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(codes)
+			index[name] = i
+			codes = append(codes, CountryCode{Name: name})
+		}
+		if prefix := strings.TrimSpace(record[1]); prefix != "" {
+			codes[i].Prefixes = append(codes[i].Prefixes, prefix)
+		}
+	}
+	return codes, nil
+}
+
+func formatCountryCSV(codes []CountryCode) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff") // 带 BOM，Excel 打开时中文不乱码
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"country", "prefix"})
+	for _, c := range codes {
+		for _, prefix := range c.Prefixes {
+			writer.Write([]string{c.Name, prefix})
+		}
+	}
+	writer.Flush()
+	return buf.Bytes()
+}
+
+// CountryIssue 区号表校验发现的问题
+type CountryIssue struct {
+	Fatal   bool // 为 true 时必须修正才能使用，否则只是提醒
+	Message string
+}
+
+// ValidateCountryCodes 校验区号表：国家名为空或重复、前缀不是数字、
+// 同一前缀属于多个国家为冲突；一个前缀是另一个国家前缀的开头为重叠（按最长前缀匹配，仅提醒），
+// 内置区号表中已有的细分规则（如 +7 中的哈萨克斯坦 76/77）不提醒；同一国家的前缀互相包含或重复时也仅提醒。
+func ValidateCountryCodes(codes []CountryCode) []CountryIssue {
+	var issues []CountryIssue
+	fatal := func(format string, args ...interface{}) {
+		issues = append(issues, CountryIssue{Fatal: true, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(format string, args ...interface{}) {
+		issues = append(issues, CountryIssue{Message: fmt.Sprintf(format, args...)})
+	}
+
+	names := make(map[string]bool)
+	owner := make(map[string]string) // 前缀 -> 国家
+	for _, c := range codes {
+		if strings.TrimSpace(c.Name) == "" {
+			fatal("存在没有名称的国家")
+		} else if names[c.Name] {
+			fatal("国家名称重复: %s", c.Name)
+		}
+		names[c.Name] = true
+		if c.Name == UnknownCountry {
+			fatal("国家名称不能使用保留名称: %s", UnknownCountry)
+		}
+		if len(c.Prefixes) == 0 {
+			warn("%s 没有任何前缀", c.Name)
+		}
+
+		for _, prefix := range c.Prefixes {
+			if !isDigits(prefix) {
+				fatal("%s 的前缀 %q 不是纯数字", c.Name, prefix)
+				continue
+			}
+			if other, ok := owner[prefix]; ok {
+				if other == c.Name {
+					warn("%s 的前缀 %s 重复", c.Name, prefix)
+				} else {
+					fatal("前缀 %s 同时属于 %s 和 %s", prefix, other, c.Name)
+				}
+				continue
+			}
+			owner[prefix] = c.Name
+		}
+	}
+
+	// 重叠：较短的前缀是较长前缀的开头
+	builtin := make(map[string]string)
+	defaults, _ := builtinCountryCodes()
+	for _, c := range defaults {
+		for _, prefix := range c.Prefixes {
+			builtin[prefix] = c.Name
+		}
+	}
+	prefixes := make([]string, 0, len(owner))
+	for prefix := range owner {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		for n := len(prefix) - 1; n > 0; n-- {
+			shorter := prefix[:n]
+			other, ok := owner[shorter]
+			if !ok {
+				continue
+			}
+			if other == owner[prefix] {
+				warn("%s 的前缀 %s 已被前缀 %s 包含", other, prefix, shorter)
+			} else if builtin[prefix] != owner[prefix] || builtin[shorter] != other {
+				warn("%s 的前缀 %s 与 %s 的前缀 %s 重叠，以 %s 开头的号码归属 %s", owner[prefix], prefix, other, shorter, prefix, owner[prefix])
+			}
+			break
+		}
+	}
+	return issues
+}
+
+// 有冲突时返回第一条冲突作为错误
+func checkCountryIssues(codes []CountryCode) error {
+	for _, issue := range ValidateCountryCodes(codes) {
+		if issue.Fatal {
+			return fmt.Errorf("区号表有冲突: %s", issue.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCountryCodes(t *testing.T) {
+	builtin, _ := builtinCountryCodes()
+	tests := []struct {
+		name   string
+		codes  []CountryCode
+		fatal  int
+		warn   int
+		errMsg string // 第一条问题应包含的内容
+	}{
+		{"内置区号表", builtin, 0, 0, ""},
+		{"内置的细分规则不提醒", []CountryCode{{"俄罗斯", []string{"7"}}, {"哈萨克斯坦", []string{"76", "77"}}}, 0, 0, ""},
+		{"不同国家的前缀重叠", []CountryCode{{"中国", []string{"86"}}, {"测试", []string{"8613"}}}, 0, 1, "重叠"},
+		{"内置前缀改归其他国家", []CountryCode{{"俄罗斯", []string{"7"}}, {"测试", []string{"77"}}}, 0, 1, "重叠"},
+		{"同一国家的前缀互相包含", []CountryCode{{"中国", []string{"86", "8613"}}}, 0, 1, "包含"},
+		{"同一国家的前缀重复", []CountryCode{{"中国", []string{"86", "86"}}}, 0, 1, "重复"},
+		{"同一前缀属于两个国家", []CountryCode{{"甲", []string{"99"}}, {"乙", []string{"99"}}}, 1, 0, "同时属于"},
+		{"前缀不是数字", []CountryCode{{"甲", []string{"+99"}}}, 1, 0, "不是纯数字"},
+		{"国家名称重复", []CountryCode{{"甲", []string{"98"}}, {"甲", []string{"99"}}}, 1, 0, "名称重复"},
+		{"保留名称", []CountryCode{{UnknownCountry, []string{"99"}}}, 1, 0, "保留名称"},
+		{"没有前缀", []CountryCode{{"甲", nil}}, 0, 1, "没有任何前缀"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := ValidateCountryCodes(tt.codes)
+			fatal, warn := 0, 0
+			for _, issue := range issues {
+				if issue.Fatal {
+					fatal++
+				} else {
+					warn++
+				}
+			}
+			if fatal != tt.fatal || warn != tt.warn {
+				t.Fatalf("冲突/提醒 = %d/%d，期望 %d/%d: %v", fatal, warn, tt.fatal, tt.warn, issues)
+			}
+			if tt.errMsg != "" && !strings.Contains(issues[0].Message, tt.errMsg) {
+				t.Errorf("问题 %q 不包含 %q", issues[0].Message, tt.errMsg)
+			}
+		})
+	}
+}
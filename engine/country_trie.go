@@ -0,0 +1,70 @@
+package engine
+
+// CountryMatcher 按区号表识别国家的数字字典树，构建一次后可并发使用。
+// 每个号码只需按位走一遍（O(号码长度)），最长的匹配前缀总是优先，与区号表中的顺序无关。
+type CountryMatcher struct {
+	nodes     []countryTrieNode
+	countries []string
+}
+
+type countryTrieNode struct {
+	next    [10]int32 // 子节点下标，0 表示没有（根节点下标为 0，不会作为子节点）
+	country int32     // 以此结尾的前缀所属国家在 countries 中的下标 +1，0 表示不是完整前缀
+}
+
+// NewCountryMatcher 根据区号表构建匹配器。同一前缀出现多次时以第一次为准，
+// 不是纯数字的前缀会被忽略（ValidateCountryCodes 会报告这两种情况）。
+func NewCountryMatcher(codes []CountryCode) *CountryMatcher {
+	m := &CountryMatcher{nodes: make([]countryTrieNode, 1)}
+	for _, c := range codes {
+		m.countries = append(m.countries, c.Name)
+		country := int32(len(m.countries))
+		for _, prefix := range c.Prefixes {
+			if !isDigits(prefix) {
+				continue
+			}
+			node := int32(0)
+			for i := 0; i < len(prefix); i++ {
+				digit := prefix[i] - '0'
+				if m.nodes[node].next[digit] == 0 {
+					m.nodes = append(m.nodes, countryTrieNode{})
+					m.nodes[node].next[digit] = int32(len(m.nodes) - 1)
+				}
+				node = m.nodes[node].next[digit]
+			}
+			if m.nodes[node].country == 0 {
+				m.nodes[node].country = country
+			}
+		}
+	}
+	return m
+}
+
+// Match 返回号码所属国家和匹配到的前缀，无法识别时返回 UnknownCountry 和空前缀
+func (m *CountryMatcher) Match(phoneNumber string) (country, prefix string) {
+	best, bestLen := int32(0), 0
+	node := int32(0)
+	for i := 0; i < len(phoneNumber); i++ {
+		c := phoneNumber[i]
+		if c < '0' || c > '9' {
+			break
+		}
+		node = m.nodes[node].next[c-'0']
+		if node == 0 {
+			break
+		}
+		if m.nodes[node].country != 0 {
+			best, bestLen = m.nodes[node].country, i+1
+		}
+	}
+	if best == 0 {
+		return UnknownCountry, ""
+	}
+	return m.countries[best-1], phoneNumber[:bestLen]
+}
+
+// Identify 返回号码所属国家，无法识别时返回 UnknownCountry
+func (m *CountryMatcher) Identify(phoneNumber string) string {
+	country, _ := m.Match(phoneNumber)
+	return country
+}
@@ -0,0 +1,98 @@
+package engine
+
+import "testing"
+
+// 匹配结果与区号表中的顺序无关，同一前缀重复时以第一次为准
+func TestCountryMatcherOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		codes   []CountryCode
+		number  string
+		country string
+	}{
+		{"长前缀在后", []CountryCode{{"英国", []string{"44"}}, {"泽西岛", []string{"447700"}}}, "447700900123", "泽西岛"},
+		{"长前缀在前", []CountryCode{{"泽西岛", []string{"447700"}}, {"英国", []string{"44"}}}, "447700900123", "泽西岛"},
+		{"未命中长前缀时回退", []CountryCode{{"泽西岛", []string{"447700"}}, {"英国", []string{"44"}}}, "447701900123", "英国"},
+		{"号码比前缀短", []CountryCode{{"泽西岛", []string{"447700"}}, {"英国", []string{"44"}}}, "4477", "英国"},
+		{"重复前缀以第一次为准", []CountryCode{{"甲", []string{"99"}}, {"乙", []string{"99"}}}, "991", "甲"},
+		{"忽略非数字前缀", []CountryCode{{"甲", []string{"+1"}}, {"乙", []string{"1"}}}, "12", "乙"},
+		{"空表", nil, "12", UnknownCountry},
+		{"空号码", []CountryCode{{"乙", []string{"1"}}}, "", UnknownCountry},
+		{"带加号的号码需要先规范化", []CountryCode{{"乙", []string{"1"}}}, "+12", UnknownCountry},
+		{"非数字", []CountryCode{{"乙", []string{"1"}}}, "abc", UnknownCountry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCountryMatcher(tt.codes).Identify(tt.number); got != tt.country {
+				t.Errorf("Identify(%q) = %s，期望 %s", tt.number, got, tt.country)
+			}
+		})
+	}
+}
+
+// 内置区号表按北美编号计划的区号区分国家和地区
+func TestCountryMatcherNANP(t *testing.T) {
+	codes, _ := builtinCountryCodes()
+	m := NewCountryMatcher(codes)
+
+	tests := []struct {
+		number  string
+		country string
+		prefix  string
+	}{
+		{"12125551234", "美国", "1212"},
+		{"14165551234", "加拿大", "1416"},
+		{"17875551234", "波多黎各", "1787"},
+		{"18095551234", "多米尼加", "1809"},
+		{"18765551234", "牙买加", "1876"},
+		{"12685551234", "安提瓜和巴布达", "1268"},
+		{"16845551234", "美属萨摩亚", "1684"},
+		{"18005551234", "北美免费电话", "1800"},
+		{"15005551234", "北美非地理号码", "1500"},
+		{"16215551234", "北美非地理号码", "1621"},
+		{"18805551234", "北美非地理号码", "1880"},
+		{"18825551234", "北美非地理号码", "1882"},
+		{"11005551234", UnknownCountry, ""}, // 1 后面不能是 0 或 1
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			country, prefix := m.Match(tt.number)
+			if country != tt.country || prefix != tt.prefix {
+				t.Errorf("Match(%q) = %s, %q，期望 %s, %q", tt.number, country, prefix, tt.country, tt.prefix)
+			}
+		})
+	}
+}
+
+// 共用国家码的地区按更长的前缀细分：哈萨克斯坦的 76/77 优先于俄罗斯的 7，皇家属地的号段优先于英国的 44
+func TestCountryMatcherSharedCodes(t *testing.T) {
+	codes, _ := builtinCountryCodes()
+	m := NewCountryMatcher(codes)
+
+	tests := []struct {
+		number  string
+		country string
+		prefix  string
+	}{
+		{"79161234567", "俄罗斯", "7"},
+		{"74951234567", "俄罗斯", "7"},
+		{"77011234567", "哈萨克斯坦", "77"},
+		{"76001234567", "哈萨克斯坦", "76"},
+		{"447123456789", "英国", "44"},
+		{"442071234567", "英国", "44"},
+		{"447700900123", "泽西岛", "447700"},
+		{"441534123456", "泽西岛", "441534"},
+		{"447911123456", "根西岛", "447911"},
+		{"441481123456", "根西岛", "441481"},
+		{"447624123456", "马恩岛", "447624"},
+		{"441624123456", "马恩岛", "441624"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			country, prefix := m.Match(tt.number)
+			if country != tt.country || prefix != tt.prefix {
+				t.Errorf("Match(%q) = %s, %q，期望 %s, %q", tt.number, country, prefix, tt.country, tt.prefix)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package engine
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultMemoryBudget 去重默认内存预算，估算内存超出时改用磁盘去重
+const DefaultMemoryBudget int64 = 1 << 30 // 1GB
+
+// 内存去重时每字节输入大约占用的内存（map 条目开销 + 字符串本身），
+// 纯数字文件使用 numberSet，见 numericMemoryFactor
+const dedupMemoryFactor = 6
+
+// 磁盘去重分桶数量范围
+const (
+	minSpillBuckets = 16
+	maxSpillBuckets = 512
+)
+
+// 根据输入大小判断是否需要磁盘去重，返回 0 表示内存足够
+func spillBucketCount(inputs []string, budget int64) int {
+	if budget == 0 {
+		budget = DefaultMemoryBudget
+	}
+	if budget < 0 {
+		return 0 // 负数表示始终使用内存
+	}
+	factor := int64(numericMemoryFactor)
+	if len(inputs) == 0 {
+		factor = dedupMemoryFactor
+	}
+	for _, input := range inputs {
+		// 任一输入不是纯数字时按普通文本估算，避免低估内存
+		if !looksNumeric(input) {
+			factor = dedupMemoryFactor
+			break
+		}
+	}
+	estimated := totalSize(inputs...) * factor
+	if estimated <= budget {
+		return 0
+	}
+
+	// 每个桶在内存中处理，留出一倍余量
+	buckets := int(estimated/budget+1) * 2
+	if buckets < minSpillBuckets {
+		buckets = minSpillBuckets
+	}
+	if buckets > maxSpillBuckets {
+		buckets = maxSpillBuckets
+	}
+	return buckets
+}
+
+// 创建磁盘去重用的临时目录，dir 为空时使用系统临时目录
+func makeSpillDir(dir string) (string, error) {
+	spillDir, err := os.MkdirTemp(dir, "ts-merge-dedup-")
+	if err != nil {
+		return "", fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	return spillDir, nil
+}
+
+// 按首次出现顺序去重的行集合，两行的 key 相同即视为重复，输出第一次出现的原始行。
+// add 依次接收所有行，finish 在输入结束后输出尚未输出的唯一行；
+// 内存实现在 add 时立即输出，磁盘实现全部在 finish 中输出。
+type lineDeduper interface {
+	add(key, line string, emit func(string) error) error
+	finish(ctx context.Context, emit func(string) error) error
+	close()
+}
+
+// 根据内存预算创建去重器，keyOf 用于磁盘去重时从原始行重新计算 key
+func newLineDeduper(inputs []string, budget int64, tempDir string, keyOf func(string) string) (lineDeduper, error) {
+	buckets := spillBucketCount(inputs, budget)
+	if buckets == 0 {
+		return memoryDeduper{newLineSet()}, nil
+	}
+	return newSpillDeduper(tempDir, buckets, keyOf)
+}
+
+// 内存去重
+type memoryDeduper struct {
+	seen *lineSet
+}
+
+func (d memoryDeduper) add(key, line string, emit func(string) error) error {
+	if !d.seen.add(key) {
+		return nil
+	}
+	return emit(line)
+}
+
+func (d memoryDeduper) finish(context.Context, func(string) error) error { return nil }
+func (d memoryDeduper) close()                                           {}
+
+// 磁盘去重：按哈希把行分到若干桶文件，逐桶在内存中去重，
+// 再按原始行号归并各桶结果，输出顺序与内存去重完全一致。
+type spillDeduper struct {
+	dir     string
+	buckets *spillBuckets
+	seq     uint64
+	keyOf   func(string) string
+}
+
+func newSpillDeduper(tempDir string, buckets int, keyOf func(string) string) (*spillDeduper, error) {
+	dir, err := makeSpillDir(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newSpillBuckets(dir, "in", buckets)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &spillDeduper{dir: dir, buckets: b, keyOf: keyOf}, nil
+}
+
+func (d *spillDeduper) add(key, line string, _ func(string) error) error {
+	d.seq++
+	return d.buckets.add(key, spillRecord{seq: d.seq, line: line})
+}
+
+func (d *spillDeduper) finish(ctx context.Context, emit func(string) error) error {
+	paths, err := d.buckets.close()
+	if err != nil {
+		return err
+	}
+
+	// 逐桶去重，每个桶内记录按行号递增，保留第一次出现的记录
+	uniquePaths := make([]string, 0, len(paths))
+	for i, path := range paths {
+		if err := checkCanceled(ctx); err != nil {
+			return err
+		}
+		uniquePath := filepath.Join(d.dir, fmt.Sprintf("unique_%d", i))
+		if err := dedupBucket(path, uniquePath, d.keyOf); err != nil {
+			return err
+		}
+		os.Remove(path)
+		uniquePaths = append(uniquePaths, uniquePath)
+	}
+
+	return mergeSpillFiles(ctx, uniquePaths, func(rec spillRecord) error {
+		return emit(rec.line)
+	})
+}
+
+func (d *spillDeduper) close() {
+	d.buckets.close()
+	os.RemoveAll(d.dir)
+}
+
+// 对单个桶去重，结果仍按行号递增写出
+func dedupBucket(inPath, outPath string, keyOf func(string) string) error {
+	seen := newLineSet()
+	out, err := newSpillWriter(outPath)
+	if err != nil {
+		return err
+	}
+	err = readSpillFile(inPath, func(rec spillRecord) error {
+		if !seen.add(keyOf(rec.line)) {
+			return nil
+		}
+		return out.write(rec)
+	})
+	if closeErr := out.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// 临时文件中的一条记录：原始行号 + 行内容
+type spillRecord struct {
+	seq  uint64
+	line string
+}
+
+// 按行哈希分桶写入的一组临时文件
+type spillBuckets struct {
+	writers []*spillWriter
+	paths   []string
+}
+
+func newSpillBuckets(dir, prefix string, count int) (*spillBuckets, error) {
+	b := &spillBuckets{}
+	for i := 0; i < count; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%d", prefix, i))
+		w, err := newSpillWriter(path)
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+		b.writers = append(b.writers, w)
+		b.paths = append(b.paths, path)
+	}
+	return b, nil
+}
+
+// key 相同的行总是落在同一个桶
+func (b *spillBuckets) bucketOf(key string) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(len(b.writers)))
+}
+
+func (b *spillBuckets) add(key string, rec spillRecord) error {
+	return b.writers[b.bucketOf(key)].write(rec)
+}
+
+// 关闭所有桶文件，返回桶文件路径；可重复调用
+func (b *spillBuckets) close() ([]string, error) {
+	var firstErr error
+	for _, w := range b.writers {
+		if err := w.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	b.writers = nil
+	return b.paths, firstErr
+}
+
+// 临时记录文件写入器
+type spillWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+	buf    [binary.MaxVarintLen64]byte
+}
+
+func newSpillWriter(path string) (*spillWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	return &spillWriter{file: file, writer: bufio.NewWriterSize(file, 64*1024)}, nil
+}
+
+func (w *spillWriter) write(rec spillRecord) error {
+	n := binary.PutUvarint(w.buf[:], rec.seq)
+	if _, err := w.writer.Write(w.buf[:n]); err != nil {
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	n = binary.PutUvarint(w.buf[:], uint64(len(rec.line)))
+	if _, err := w.writer.Write(w.buf[:n]); err != nil {
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if _, err := w.writer.WriteString(rec.line); err != nil {
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	return nil
+}
+
+func (w *spillWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.writer.Flush()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	return nil
+}
+
+// 临时记录文件读取器
+type spillReader struct {
+	file   *os.File
+	reader *bufio.Reader
+}
+
+func openSpillReader(path string) (*spillReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开临时文件失败: %v", err)
+	}
+	return &spillReader{file: file, reader: bufio.NewReaderSize(file, 64*1024)}, nil
+}
+
+// 读取下一条记录，读完时返回 io.EOF
+func (r *spillReader) next() (spillRecord, error) {
+	seq, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return spillRecord{}, err
+	}
+	length, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return spillRecord{}, fmt.Errorf("临时文件已损坏: %v", err)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, buf); err != nil {
+		return spillRecord{}, fmt.Errorf("临时文件已损坏: %v", err)
+	}
+	return spillRecord{seq: seq, line: string(buf)}, nil
+}
+
+func (r *spillReader) close() {
+	r.file.Close()
+}
+
+// 依次读取临时文件中的所有记录
+func readSpillFile(path string, fn func(spillRecord) error) error {
+	r, err := openSpillReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.close()
+
+	for {
+		rec, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// 归并若干个按行号递增的临时文件，按行号从小到大回调
+func mergeSpillFiles(ctx context.Context, paths []string, fn func(spillRecord) error) error {
+	h := &spillHeap{}
+	defer func() {
+		for _, item := range *h {
+			item.reader.close()
+		}
+	}()
+
+	for _, path := range paths {
+		r, err := openSpillReader(path)
+		if err != nil {
+			return err
+		}
+		rec, err := r.next()
+		if err == io.EOF {
+			r.close()
+			continue
+		}
+		if err != nil {
+			r.close()
+			return err
+		}
+		heap.Push(h, &spillHeapItem{rec: rec, reader: r})
+	}
+
+	for count := 1; h.Len() > 0; count++ {
+		if count%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return err
+			}
+		}
+
+		item := (*h)[0]
+		if err := fn(item.rec); err != nil {
+			return err
+		}
+
+		rec, err := item.reader.next()
+		if err == io.EOF {
+			item.reader.close()
+			heap.Pop(h)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		item.rec = rec
+		heap.Fix(h, 0)
+	}
+	return nil
+}
+
+type spillHeapItem struct {
+	rec    spillRecord
+	reader *spillReader
+}
+
+// 按行号排序的最小堆
+type spillHeap []*spillHeapItem
+
+func (h spillHeap) Len() int            { return len(h) }
+func (h spillHeap) Less(i, j int) bool  { return h[i].rec.seq < h[j].rec.seq }
+func (h spillHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *spillHeap) Push(x interface{}) { *h = append(*h, x.(*spillHeapItem)) }
+func (h *spillHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 生成有重复的号码：同一号码以不同写法出现，夹杂空行和非号码行
+func dedupTestLines(n, distinct, offset int) []string {
+	lines := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		v := (i*7919 + offset) % distinct
+		switch i % 5 {
+		case 0:
+			lines = append(lines, fmt.Sprintf("86138%08d", v))
+		case 1:
+			lines = append(lines, fmt.Sprintf("+86 138-%04d-%04d", v/10000, v%10000))
+		case 2:
+			lines = append(lines, fmt.Sprintf("0086138%08d", v))
+		case 3:
+			lines = append(lines, fmt.Sprintf("备注%d", v%50))
+		default:
+			lines = append(lines, "")
+		}
+	}
+	return lines
+}
+
+// 磁盘去重与内存去重的输出必须逐字节一致
+func TestMergeDiskDedupMatchesMemory(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputs    [][]string
+		normalize NormalizeOptions
+	}{
+		{"单个文件", [][]string{dedupTestLines(3000, 400, 0)}, NormalizeOptions{}},
+		{"多个文件", [][]string{dedupTestLines(2000, 300, 0), dedupTestLines(2000, 300, 17)}, NormalizeOptions{}},
+		{"规范化后去重", [][]string{dedupTestLines(2000, 300, 0), dedupTestLines(2000, 300, 17)}, DefaultNormalizeOptions()},
+		{"规范化并输出 E.164", [][]string{dedupTestLines(2000, 300, 5)}, NormalizeOptions{StripSeparators: true, InternationalPrefix: true, Format: FormatE164}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var inputs []string
+			for i, lines := range tt.inputs {
+				inputs = append(inputs, writeTestFile(t, dir, fmt.Sprintf("in%d.txt", i), lines))
+			}
+
+			run := func(name string, budget int64) (*MergeResult, string) {
+				output := filepath.Join(dir, name)
+				result, err := Merge(context.Background(), MergeOptions{
+					Inputs:       inputs,
+					Output:       output,
+					Dedup:        true,
+					Normalize:    tt.normalize,
+					MemoryBudget: budget,
+				}, nil)
+				if err != nil {
+					t.Fatalf("合并失败: %v", err)
+				}
+				return result, readTestFile(t, output)
+			}
+
+			memResult, memOutput := run("memory.txt", -1)
+			// 预算小于估算的内存占用，强制使用磁盘去重
+			diskResult, diskOutput := run("disk.txt", totalSize(inputs...))
+			if memResult.DiskDedup || !diskResult.DiskDedup {
+				t.Fatalf("DiskDedup = %v/%v，期望 false/true", memResult.DiskDedup, diskResult.DiskDedup)
+			}
+			if diskOutput != memOutput {
+				t.Errorf("磁盘去重的输出与内存去重不同")
+			}
+			if diskResult.LinesWritten != memResult.LinesWritten || diskResult.Duplicates != memResult.Duplicates {
+				t.Errorf("写入/重复 = %d/%d，期望 %d/%d",
+					diskResult.LinesWritten, diskResult.Duplicates, memResult.LinesWritten, memResult.Duplicates)
+			}
+		})
+	}
+}
+
+// 磁盘比较与内存比较的两个输出文件必须逐字节一致
+func TestCompareDiskMatchesMemory(t *testing.T) {
+	tests := []struct {
+		name      string
+		file1     []string
+		file2     []string
+		normalize NormalizeOptions
+		wantSame  int // 相同内容的行数，-1 表示不检查
+	}{
+		{"部分重叠", dedupTestLines(2000, 300, 0), dedupTestLines(2000, 300, 17), NormalizeOptions{}, -1},
+		{"规范化后比较", dedupTestLines(2000, 300, 0), dedupTestLines(1500, 200, 3), DefaultNormalizeOptions(), -1},
+		{"完全相同", []string{"1", "2", "2", "3"}, []string{"3", "2", "1"}, NormalizeOptions{}, 3},
+		{"没有交集", []string{"1", "2"}, []string{"3", "4", "4"}, NormalizeOptions{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			file1 := writeTestFile(t, dir, "a.txt", tt.file1)
+			file2 := writeTestFile(t, dir, "b.txt", tt.file2)
+
+			run := func(name string, budget int64) (*CompareResult, string, string) {
+				outputDir := filepath.Join(dir, name)
+				if err := os.MkdirAll(outputDir, 0755); err != nil {
+					t.Fatalf("创建输出目录失败: %v", err)
+				}
+				result, err := Compare(context.Background(), CompareOptions{
+					File1:        file1,
+					File2:        file2,
+					OutputDir:    outputDir,
+					Normalize:    tt.normalize,
+					MemoryBudget: budget,
+				}, nil)
+				if err != nil {
+					t.Fatalf("比较失败: %v", err)
+				}
+				return result, readTestFile(t, result.SameFile), readTestFile(t, result.DiffFile)
+			}
+
+			memResult, memSame, memDiff := run("memory", -1)
+			diskResult, diskSame, diskDiff := run("disk", totalSize(file1, file2))
+			if memResult.DiskMode || !diskResult.DiskMode {
+				t.Fatalf("DiskMode = %v/%v，期望 false/true", memResult.DiskMode, diskResult.DiskMode)
+			}
+			if diskSame != memSame {
+				t.Errorf("磁盘比较的相同内容与内存比较不同")
+			}
+			if diskDiff != memDiff {
+				t.Errorf("磁盘比较的不同内容与内存比较不同")
+			}
+			if diskResult.SameLines != memResult.SameLines || diskResult.DiffLines != memResult.DiffLines {
+				t.Errorf("相同/不同 = %d/%d，期望 %d/%d",
+					diskResult.SameLines, diskResult.DiffLines, memResult.SameLines, memResult.DiffLines)
+			}
+			if tt.wantSame >= 0 && memResult.SameLines != tt.wantSame {
+				t.Errorf("相同内容 %d 行，期望 %d 行", memResult.SameLines, tt.wantSame)
+			}
+		})
+	}
+}
+
+// 任一输入不是纯数字时按普通文本估算内存
+func TestSpillBucketCountSamplesEveryInput(t *testing.T) {
+	dir := t.TempDir()
+	var numeric []string
+	for i := 0; i < 1000; i++ {
+		numeric = append(numeric, fmt.Sprintf("86138%08d", i))
+	}
+	numbers := writeTestFile(t, dir, "numbers.txt", numeric)
+	numbers2 := writeTestFile(t, dir, "numbers2.txt", numeric)
+	text := writeTestFile(t, dir, "text.txt", []string{"备注", "86138000"})
+
+	tests := []struct {
+		name   string
+		inputs []string
+		spill  bool
+	}{
+		{"全部是号码", []string{numbers, numbers2}, false},
+		{"第一个是号码", []string{numbers, text}, true},
+		{"最后一个是号码", []string{text, numbers}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 预算刚好够纯数字文件使用
+			budget := totalSize(tt.inputs...) * numericMemoryFactor
+			if got := spillBucketCount(tt.inputs, budget) > 0; got != tt.spill {
+				t.Errorf("使用磁盘去重 = %v，期望 %v", got, tt.spill)
+			}
+		})
+	}
+}
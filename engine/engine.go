@@ -0,0 +1,42 @@
+// Package engine 提供与界面无关的文件处理核心逻辑。
+// GUI、命令行和测试都通过这里的选项结构体驱动同一套实现，
+// 处理过程中的进度与状态通过 Reporter 回调通知调用方。
+package engine
+
+import (
+	"bufio"
+	"io"
+)
+
+// Reporter 接收处理过程中的进度和状态事件
+type Reporter interface {
+	// Progress 报告当前进度及速度统计，调用频率已做节流
+	Progress(stats Stats)
+	// Status 报告当前阶段的文字说明
+	Status(text string)
+}
+
+type nopReporter struct{}
+
+func (nopReporter) Progress(Stats) {}
+func (nopReporter) Status(string)  {}
+
+// NopReporter 丢弃所有事件，适用于不关心进度的调用方
+var NopReporter Reporter = nopReporter{}
+
+// 调用方未提供 Reporter 时使用空实现
+func reporterOrNop(r Reporter) Reporter {
+	if r == nil {
+		return NopReporter
+	}
+	return r
+}
+
+// 创建逐行读取的扫描器
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	// 设置更大的缓冲区以处理长行，避免 "token too long" 错误
+	buf := make([]byte, 0, 128*1024) // 128KB初始缓冲区
+	scanner.Buffer(buf, 2*1024*1024) // 2MB最大行长度
+	return scanner
+}
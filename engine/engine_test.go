@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 在 dir 中创建测试输入文件，每个元素一行
+func writeTestFile(t *testing.T, dir, name string, lines []string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	return path
+}
+
+// 读取输出文件的全部内容
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取输出文件失败: %v", err)
+	}
+	return string(data)
+}
+
+// 读取输出文件的各行（不含最后的空行）
+func readTestLines(t *testing.T, path string) []string {
+	t.Helper()
+	text := strings.TrimSuffix(readTestFile(t, path), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
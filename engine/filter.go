@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilterOptions 按前缀过滤参数
+type FilterOptions struct {
+	Input    string   // 要过滤的文件
+	Output   string   // 输出文件路径
+	Prefixes []string // 只保留以这些前缀开头的行，按号码类型处理时可以为空（不限前缀）
+	Exclude  bool     // 排除模式：丢弃以这些前缀开头的行，保留其余行
+
+	// 同时把未保留的行（不符合前缀条件或不是手机号）写入 输出_dropped.txt，一遍读完得到两个文件；
+	// 校验不合格的行仍只写入 _rejects.txt
+	WriteDropped bool
+
+	Normalize NormalizeOptions // 号码规范化规则，按规范化后的号码匹配前缀
+	Types     TypeMode         // 按号码类型处理：只保留手机号，或每种类型写入 输出_手机.txt 等文件
+	Rejects   bool             // 按各国号码长度校验每一行，不合格的行不写入输出，记录到 输出_rejects.txt
+}
+
+// FilterResult 按前缀过滤结果
+type FilterResult struct {
+	Output      string `json:"output"`
+	LinesRead   int    `json:"lines_read"`   // 读取的行数（含空行）
+	LinesKept   int    `json:"lines_kept"`   // 保留的行数
+	NonMobile   int    `json:"non_mobile"`   // 只保留手机号时丢弃的行数
+	UnknownType int    `json:"unknown_type"` // 只保留手机号时无法判断类型、仍然保留的行数
+	Rejected    int    `json:"rejected"`     // 校验不合格的行数
+
+	LinesDropped  int    `json:"lines_dropped"`            // 未保留的行数（不含空行和校验不合格的行）
+	DroppedOutput string `json:"dropped_output,omitempty"` // 未保留的行所在文件，只在 WriteDropped 时有
+
+	RejectsFile string `json:"rejects_file,omitempty"` // 不合格的行所在文件，没有时为空
+
+	PrefixCounts []PrefixCount `json:"prefix_counts,omitempty"` // 每个前缀匹配的行数，顺序与输入一致
+
+	TypeOutputs map[string]string `json:"type_outputs,omitempty"` // 按类型分别输出时，类型 -> 输出文件
+	TypeCounts  map[string]int    `json:"type_counts,omitempty"`  // 按类型分别输出时，类型 -> 行数
+}
+
+// PrefixCount 单个前缀匹配的行数，多个前缀都匹配时只计入最长的
+type PrefixCount struct {
+	Prefix string `json:"prefix"` // 规范化后的前缀
+	Count  int    `json:"count"`
+}
+
+// 未保留的行写入的文件：输出_dropped.txt
+func droppedPath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + "_dropped.txt"
+}
+
+// 按类型分别输出时的文件名：输出_类型.txt
+func typeOutputPath(output string, t NumberType) string {
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "_" + t.String() + ext
+}
+
+// Filter 只保留以任一前缀开头的行（排除模式下丢弃这些行），可再按号码类型只保留手机号或分类型输出，
+// 并统计每个前缀匹配的行数。取消或失败时删除未写完的输出文件。
+func Filter(ctx context.Context, opts FilterOptions, r Reporter) (result *FilterResult, err error) {
+	r = reporterOrNop(r)
+
+	// 前缀也按相同规则规范化，输入 +86 和 86 效果相同；规范化后为空的前缀（如 +、-）忽略，
+	// 全部为空时不能当作不限前缀
+	keys := make([]string, 0, len(opts.Prefixes))
+	for _, prefix := range opts.Prefixes {
+		keys = append(keys, opts.Normalize.Key(prefix))
+	}
+	prefixes := newPrefixMatcher(keys)
+	if len(prefixes.prefixes) == 0 && (len(opts.Prefixes) > 0 || opts.Types == TypeAll || opts.Exclude) {
+		return nil, fmt.Errorf("请至少输入一个号码前缀")
+	}
+
+	// 删除已存在的输出文件（按类型分别输出时不写这个文件，保留不动）
+	if _, err := os.Stat(opts.Output); err == nil && opts.Types != TypeSplit {
+		os.Remove(opts.Output)
+	}
+
+	file, err := os.Open(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	outputs := &outputTracker{}
+	buckets := newBucketWriter(outputs, maxOpenBuckets)
+	defer func() {
+		if err != nil {
+			buckets.abort()
+			outputs.removeAll()
+		}
+	}()
+
+	rejects := newRejectWriter(opts.Rejects, outputs, rejectsPath(opts.Output))
+	defer rejects.close()
+
+	// 按类型分别输出时不生成 Output 本身
+	var writer *bufio.Writer
+	if opts.Types != TypeSplit {
+		outputFile, err := outputs.create(opts.Output)
+		if err != nil {
+			return nil, fmt.Errorf("创建输出文件失败: %v", err)
+		}
+		defer outputFile.Close()
+
+		writer = bufio.NewWriter(outputFile)
+		defer writer.Flush()
+	}
+
+	result = &FilterResult{Output: opts.Output}
+	var dropped *bufio.Writer
+	if opts.WriteDropped {
+		result.DroppedOutput = droppedPath(opts.Output)
+		droppedFile, err := outputs.create(result.DroppedOutput)
+		if err != nil {
+			return nil, fmt.Errorf("创建输出文件失败: %v", err)
+		}
+		defer droppedFile.Close()
+
+		dropped = bufio.NewWriter(droppedFile)
+		defer dropped.Flush()
+	}
+
+	counts := make([]int, len(prefixes.prefixes))
+
+	if opts.Types == TypeSplit {
+		result.TypeOutputs = make(map[string]string)
+		result.TypeCounts = make(map[string]int)
+	}
+	meter := newProgressMeter(r, totalSize(opts.Input))
+	scanner := newScanner(meter.track(file))
+
+	// 逐行读取并过滤
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		result.LinesRead++
+		meter.line()
+		if result.LinesRead%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		// 跳过空行；检查行是否以任何一个前缀开头（没有前缀时不限），校验不合格的行只记录到 _rejects.txt
+		if key := opts.Normalize.Key(line); key != "" {
+			rejected, err := rejects.check(opts.Input, result.LinesRead, line, key)
+			if err != nil {
+				return nil, err
+			}
+			if rejected {
+				continue
+			}
+			i := prefixes.match(key)
+			if i >= 0 {
+				counts[i]++
+			}
+			kept := false
+			if len(prefixes.prefixes) == 0 || (i >= 0) != opts.Exclude {
+				if kept, err = writeFiltered(opts, result, writer, buckets, line, key); err != nil {
+					return nil, err
+				}
+			}
+			if !kept {
+				result.LinesDropped++
+				if dropped != nil {
+					if _, err := dropped.WriteString(opts.Normalize.Output(line, key) + "\n"); err != nil {
+						return nil, fmt.Errorf("写入文件失败: %v", err)
+					}
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	// 强制刷新缓冲区
+	for _, w := range []*bufio.Writer{writer, dropped} {
+		if w == nil {
+			continue
+		}
+		if err := w.Flush(); err != nil {
+			return nil, fmt.Errorf("刷新缓冲区失败: %v", err)
+		}
+	}
+	if err := buckets.close(); err != nil {
+		return nil, err
+	}
+	if result.Rejected, result.RejectsFile, err = rejects.close(); err != nil {
+		return nil, err
+	}
+
+	for i, prefix := range prefixes.prefixes {
+		result.PrefixCounts = append(result.PrefixCounts, PrefixCount{Prefix: prefix, Count: counts[i]})
+	}
+
+	meter.finish()
+	return result, nil
+}
+
+// 按号码类型处理并写入符合前缀条件的行，返回是否保留（只保留手机号时其他号码不保留）
+func writeFiltered(opts FilterOptions, result *FilterResult, writer *bufio.Writer, buckets *bucketWriter, line, key string) (bool, error) {
+	out := opts.Normalize.Output(line, key)
+	switch opts.Types {
+	case TypeMobileOnly:
+		if t := ClassifyNumber(key); t == TypeUnknown {
+			result.UnknownType++
+		} else if !t.MayBeMobile() {
+			result.NonMobile++
+			return false, nil
+		}
+	case TypeSplit:
+		t := ClassifyNumber(key)
+		path := typeOutputPath(opts.Output, t)
+		if err := buckets.write(path, out); err != nil {
+			return false, err
+		}
+		result.TypeOutputs[t.String()] = path
+		result.TypeCounts[t.String()]++
+		result.LinesKept++
+		return true, nil
+	}
+	if _, err := writer.WriteString(out + "\n"); err != nil {
+		return false, fmt.Errorf("写入文件失败: %v", err)
+	}
+	result.LinesKept++
+	return true, nil
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// 只保留手机号时丢弃确定不是手机的号码，无法判断类型的号码保留并单独计数
+func TestFilterMobileOnly(t *testing.T) {
+	lines := []string{"8613800138000", "8675512345678", "85221234567", "12125551234", "448001234567", "", "447700900123"}
+	tests := []struct {
+		name     string
+		prefixes []string
+		kept     []string
+		dropped  []string
+		nonMob   int
+		unknown  int
+	}{
+		{"不限前缀", nil, []string{"8613800138000", "85221234567", "12125551234", "447700900123"},
+			[]string{"8675512345678", "448001234567"}, 2, 1},
+		{"先按前缀过滤", []string{"86", "852"}, []string{"8613800138000", "85221234567"},
+			[]string{"8675512345678", "12125551234", "448001234567", "447700900123"}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			output := filepath.Join(dir, "out.txt")
+			result, err := Filter(context.Background(), FilterOptions{
+				Input:        writeTestFile(t, dir, "in.txt", lines),
+				Output:       output,
+				Prefixes:     tt.prefixes,
+				Types:        TypeMobileOnly,
+				WriteDropped: true,
+			}, nil)
+			if err != nil {
+				t.Fatalf("过滤失败: %v", err)
+			}
+			if got := readTestLines(t, output); !reflect.DeepEqual(got, tt.kept) {
+				t.Errorf("保留 %v，期望 %v", got, tt.kept)
+			}
+			if got := readTestLines(t, result.DroppedOutput); !reflect.DeepEqual(got, tt.dropped) {
+				t.Errorf("未保留 %v，期望 %v", got, tt.dropped)
+			}
+			if result.NonMobile != tt.nonMob || result.UnknownType != tt.unknown {
+				t.Errorf("非手机号/无法判断类型 = %d/%d，期望 %d/%d", result.NonMobile, result.UnknownType, tt.nonMob, tt.unknown)
+			}
+		})
+	}
+}
+
+// 保留或排除以前缀开头的行，未保留的行写入 _dropped.txt；前缀按规范化后的号码匹配，多个前缀都匹配时取最长的
+func TestFilterPrefixes(t *testing.T) {
+	lines := []string{"8613800138000", "+86 139 0013 9000", "", "447700900123", "  0044 20 7123 4567  ", "12125551234", "备注"}
+	tests := []struct {
+		name     string
+		prefixes []string
+		exclude  bool
+		kept     []string
+		dropped  []string
+		counts   []PrefixCount
+	}{
+		{"保留", []string{"86", "44"}, false,
+			[]string{"8613800138000", "+86 139 0013 9000", "447700900123", "0044 20 7123 4567"},
+			[]string{"12125551234", "备注"},
+			[]PrefixCount{{"86", 2}, {"44", 2}}},
+		{"排除", []string{"86", "44"}, true,
+			[]string{"12125551234", "备注"},
+			[]string{"8613800138000", "+86 139 0013 9000", "447700900123", "0044 20 7123 4567"},
+			[]PrefixCount{{"86", 2}, {"44", 2}}},
+		{"前缀带加号和分隔符", []string{"+86 139", "0044-7"}, false,
+			[]string{"+86 139 0013 9000", "447700900123"},
+			[]string{"8613800138000", "0044 20 7123 4567", "12125551234", "备注"},
+			[]PrefixCount{{"86139", 1}, {"447", 1}}},
+		{"长前缀优先", []string{"86", "8613", "861380"}, false,
+			[]string{"8613800138000", "+86 139 0013 9000"},
+			[]string{"447700900123", "0044 20 7123 4567", "12125551234", "备注"},
+			[]PrefixCount{{"86", 0}, {"8613", 1}, {"861380", 1}}},
+		{"忽略规范化后为空的前缀", []string{"+", "44", "-"}, true,
+			[]string{"8613800138000", "+86 139 0013 9000", "12125551234", "备注"},
+			[]string{"447700900123", "0044 20 7123 4567"},
+			[]PrefixCount{{"44", 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			output := filepath.Join(dir, "out.txt")
+			result, err := Filter(context.Background(), FilterOptions{
+				Input:        writeTestFile(t, dir, "in.txt", lines),
+				Output:       output,
+				Prefixes:     tt.prefixes,
+				Exclude:      tt.exclude,
+				WriteDropped: true,
+				Normalize:    DefaultNormalizeOptions(),
+			}, nil)
+			if err != nil {
+				t.Fatalf("过滤失败: %v", err)
+			}
+			if got := readTestLines(t, output); !reflect.DeepEqual(got, tt.kept) {
+				t.Errorf("保留 %q，期望 %q", got, tt.kept)
+			}
+			if result.DroppedOutput != filepath.Join(dir, "out_dropped.txt") {
+				t.Errorf("未保留的行写入 %s，期望 out_dropped.txt", result.DroppedOutput)
+			}
+			if got := readTestLines(t, result.DroppedOutput); !reflect.DeepEqual(got, tt.dropped) {
+				t.Errorf("未保留 %q，期望 %q", got, tt.dropped)
+			}
+			if result.LinesKept != len(tt.kept) || result.LinesDropped != len(tt.dropped) || result.LinesRead != len(lines) {
+				t.Errorf("读取/保留/未保留 = %d/%d/%d，期望 %d/%d/%d",
+					result.LinesRead, result.LinesKept, result.LinesDropped, len(lines), len(tt.kept), len(tt.dropped))
+			}
+			if !reflect.DeepEqual(result.PrefixCounts, tt.counts) {
+				t.Errorf("前缀统计 = %v，期望 %v", result.PrefixCounts, tt.counts)
+			}
+		})
+	}
+}
+
+// 没有有效前缀时报错，不能当作不限前缀保留全部行
+func TestFilterRequiresPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefixes []string
+		exclude  bool
+		types    TypeMode
+		wantErr  bool
+	}{
+		{"没有前缀", nil, false, TypeAll, true},
+		{"排除模式没有前缀", nil, true, TypeMobileOnly, true},
+		{"前缀规范化后全部为空", []string{"+", "-", "  "}, false, TypeAll, true},
+		{"只保留手机号时前缀规范化后全部为空", []string{"+"}, false, TypeMobileOnly, true},
+		{"只保留手机号时不限前缀", nil, false, TypeMobileOnly, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			output := filepath.Join(dir, "out.txt")
+			_, err := Filter(context.Background(), FilterOptions{
+				Input:     writeTestFile(t, dir, "in.txt", []string{"8613800138000"}),
+				Output:    output,
+				Prefixes:  tt.prefixes,
+				Exclude:   tt.exclude,
+				Types:     tt.types,
+				Normalize: DefaultNormalizeOptions(),
+			}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if tt.wantErr && err.Error() != "请至少输入一个号码前缀" {
+				t.Errorf("错误信息 = %q", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package engine
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 内置的归属地库，每行：号段或区号,省份,城市。第一列为手机号段（号码前 4~7 位，按最长前缀匹配），
+// 或 0 开头的固话区号（如 0755）。号段由 libphonenumber 的中文离线归属地数据（Apache-2.0）转换而来；
+// 区号收录各地级市的固话区号（多个城市共用或已并入其他城市的区号不收录，全省共用的区号城市为空）。
+// 号段放号或行政区划调整后需要更新该表重新打包，也可以导入新的归属地库覆盖。
+//
+//go:embed china_locations.csv
+var defaultLocationsCSV []byte
+
+// LocationsFile 放在程序旁边的归属地库文件名，导入的归属地库保存为这个文件
+const LocationsFile = "china_locations.csv"
+
+// 手机号段的位数范围：导入的库通常为 7 位号段，内置库中整段归属同一地区的号段合并为更短的前缀
+const (
+	minSegmentDigits = 4
+	maxSegmentDigits = 7
+)
+
+// UnknownLocation 不是大陆号码或号段、区号不在归属地库中时使用的名称
+const UnknownLocation = "未知归属地"
+
+// 归属地库：手机号段或固话区号 -> 省份和城市
+type locationDB struct {
+	places    []Location
+	index     map[Location]int32
+	prefixes  map[string]int32 // 手机号段（前 4~7 位） -> places 下标
+	areaCodes map[string]int32 // 固话区号（含 0） -> places 下标
+}
+
+func newLocationDB() *locationDB {
+	return &locationDB{index: make(map[Location]int32), prefixes: make(map[string]int32), areaCodes: make(map[string]int32)}
+}
+
+// 归属地在 places 中的下标，没有时添加
+func (db *locationDB) place(loc Location) int32 {
+	i, ok := db.index[loc]
+	if !ok {
+		i = int32(len(db.places))
+		db.index[loc] = i
+		db.places = append(db.places, loc)
+	}
+	return i
+}
+
+// Location 号段的归属地
+type Location struct {
+	Province string `json:"province"`
+	City     string `json:"city"`
+}
+
+var (
+	locationMu     sync.RWMutex
+	locations      *locationDB // 当前生效的归属地库，为空时使用内置库
+	defaultLocOnce sync.Once
+	defaultLocDB   *locationDB
+)
+
+func activeLocations() *locationDB {
+	locationMu.RLock()
+	db := locations
+	locationMu.RUnlock()
+	if db != nil {
+		return db
+	}
+	return activeDefaultLocations()
+}
+
+// 内置归属地库
+func activeDefaultLocations() *locationDB {
+	defaultLocOnce.Do(func() {
+		var err error
+		if defaultLocDB, err = parseLocationCSV(bytes.NewReader(defaultLocationsCSV)); err != nil {
+			panic(fmt.Sprintf("内置归属地库格式错误: %v", err))
+		}
+	})
+	return defaultLocDB
+}
+
+// LocationCount 当前归属地库中的手机号段数（4~7 位前缀的条数）
+func LocationCount() int {
+	return len(activeLocations().prefixes)
+}
+
+// AreaCodeCount 当前归属地库中的固话区号数
+func AreaCodeCount() int {
+	return len(activeLocations().areaCodes)
+}
+
+// IdentifyLocation 查询大陆号码（可带或不带 86）的归属地：手机号按号段（最长前缀优先），固话按区号，
+// 返回归属地和匹配到的号段或区号
+func IdentifyLocation(number string) (loc Location, prefix string, ok bool) {
+	db := activeLocations()
+	if mobile, ok := ChinaMobileNumber(number); ok {
+		for n := maxSegmentDigits; n >= minSegmentDigits; n-- {
+			if i, ok := db.prefixes[mobile[:n]]; ok {
+				return db.places[i], mobile[:n], true
+			}
+		}
+		return Location{}, "", false
+	}
+	national, ok := chinaFixedLine(number)
+	if !ok {
+		return Location{}, "", false
+	}
+	for _, n := range []int{4, 3} { // 4 位区号优先，如 0755；北京 010、广州 020 等为 3 位
+		if i, ok := db.areaCodes[national[:n]]; ok {
+			return db.places[i], national[:n], true
+		}
+	}
+	return Location{}, "", false
+}
+
+// 大陆固话号码转为国内格式（0 + 区号 + 号码，共 10~12 位）：可带 86、+86、0086 或直接以 0 开头
+func chinaFixedLine(number string) (national string, ok bool) {
+	switch {
+	case strings.HasPrefix(number, "+86"):
+		national = "0" + number[3:]
+	case strings.HasPrefix(number, "0086"):
+		national = "0" + number[4:]
+	case strings.HasPrefix(number, "86"):
+		national = "0" + number[2:]
+	case strings.HasPrefix(number, "0") && !strings.HasPrefix(number, "00"):
+		national = number
+	default:
+		return "", false
+	}
+	if len(national) < 10 || len(national) > 12 || !isDigits(national) || national[1] == '0' {
+		return "", false
+	}
+	return national, true
+}
+
+// 大陆号码转为带国家码的格式（86 + 国内号码），用于按各国长度规则校验：
+// 已带 86、+86、0086 的去掉前面的 + 或 00，以 0 开头的固话去掉 0，其余视为国内号码
+func chinaInternational(number string) string {
+	switch {
+	case strings.HasPrefix(number, "+86"):
+		return number[1:]
+	case strings.HasPrefix(number, "0086"):
+		return number[2:]
+	case strings.HasPrefix(number, "86"):
+		return number
+	case strings.HasPrefix(number, "0") && !strings.HasPrefix(number, "00"):
+		return "86" + number[1:]
+	}
+	return "86" + number
+}
+
+// LocationsPath 返回程序旁边的归属地库文件路径
+func LocationsPath() string {
+	dir := "."
+	if exe, err := os.Executable(); err == nil {
+		dir = filepath.Dir(exe)
+	}
+	return filepath.Join(dir, LocationsFile)
+}
+
+// LoadLocations 加载程序旁边的归属地库文件。文件不存在时使用内置库，返回的 path 为空。
+func LoadLocations() (path string, count int, err error) {
+	path = LocationsPath()
+	if _, statErr := os.Stat(path); statErr != nil {
+		return "", LocationCount(), nil
+	}
+	db, err := readLocationFile(path)
+	if err != nil {
+		return path, 0, err
+	}
+	count = len(db.prefixes) // 沿用内置号段之前的数量，只统计文件中的号段
+	setLocations(db)
+	return path, count, nil
+}
+
+// ImportLocations 导入新的归属地库文件：校验格式后复制到程序旁边并立即生效，之后启动时自动加载
+func ImportLocations(src string) (count int, err error) {
+	db, err := readLocationFile(src)
+	if err != nil {
+		return 0, err
+	}
+	if len(db.prefixes) == 0 && len(db.areaCodes) == 0 {
+		return 0, fmt.Errorf("归属地库 %s 中没有号段或区号", filepath.Base(src))
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return 0, fmt.Errorf("读取归属地库失败: %v", err)
+	}
+	if err := os.WriteFile(LocationsPath(), data, 0644); err != nil {
+		return 0, fmt.Errorf("保存归属地库失败: %v", err)
+	}
+	count = len(db.prefixes)
+	setLocations(db)
+	return count, nil
+}
+
+// 设为当前归属地库；库中没有手机号段或固话区号时沿用内置的号段或区号
+func setLocations(db *locationDB) {
+	builtin := activeDefaultLocations()
+	if len(db.prefixes) == 0 {
+		for prefix, i := range builtin.prefixes {
+			db.prefixes[prefix] = db.place(builtin.places[i])
+		}
+	}
+	if len(db.areaCodes) == 0 {
+		for code, i := range builtin.areaCodes {
+			db.areaCodes[code] = db.place(builtin.places[i])
+		}
+	}
+	locationMu.Lock()
+	locations = db
+	locationMu.Unlock()
+}
+
+func readLocationFile(path string) (*locationDB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开归属地库失败: %v", err)
+	}
+	defer file.Close()
+	db, err := parseLocationCSV(file)
+	if err != nil {
+		return nil, fmt.Errorf("解析归属地库 %s 失败: %v", filepath.Base(path), err)
+	}
+	return db, nil
+}
+
+// 每行：手机号段（1 开头的 4~7 位）或 0 开头的固话区号,省份,城市，多余的列忽略；第一列不是数字的行（如表头）跳过
+func parseLocationCSV(r io.Reader) (*locationDB, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	db := newLocationDB()
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		prefix := strings.TrimPrefix(strings.TrimSpace(record[0]), "\ufeff")
+		if !isDigits(prefix) {
+			continue
+		}
+		areaCode := prefix[0] == '0' && (len(prefix) == 3 || len(prefix) == 4)
+		segment := prefix[0] == '1' && len(prefix) >= minSegmentDigits && len(prefix) <= maxSegmentDigits
+		if !areaCode && !segment || len(record) < 3 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("第 %d 行格式错误，应为：1 开头的 4~7 位号段或 0 开头的区号,省份,城市", line)
+		}
+		i := db.place(Location{Province: strings.TrimSpace(record[1]), City: strings.TrimSpace(record[2])})
+		if areaCode {
+			db.areaCodes[prefix] = i
+		} else {
+			db.prefixes[prefix] = i
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+// 内置归属地库：手机号按最长号段匹配，固话按 4 位或 3 位区号匹配
+func TestIdentifyLocation(t *testing.T) {
+	tests := []struct {
+		number   string
+		province string
+		city     string
+		prefix   string
+		ok       bool
+	}{
+		{"13800138000", "北京", "北京", "138001", true}, // 整段归属同一地区的号段合并为较短的前缀
+		{"8613800048000", "辽宁", "沈阳", "1380004", true},
+		{"+8613900001234", "新疆", "乌鲁木齐", "1390000", true},
+		{"18612345678", "北京", "北京", "1861", true},
+		{"075512345678", "广东", "深圳", "0755", true},
+		{"8675512345678", "广东", "深圳", "0755", true},
+		{"01012345678", "北京", "北京", "010", true},
+		{"02812345678", "四川", "成都", "028", true},
+		{"033512345678", "河北", "秦皇岛", "0335", true},
+		{"089812345678", "海南", "", "0898", true}, // 全省共用的区号
+		{"12125551234", "", "", "", false},
+		{"0755123", "", "", "", false}, // 号码位数不够
+		{"442071234567", "", "", "", false},
+		{"", "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			loc, prefix, ok := IdentifyLocation(tt.number)
+			if loc.Province != tt.province || loc.City != tt.city || prefix != tt.prefix || ok != tt.ok {
+				t.Errorf("IdentifyLocation(%q) = %v, %q, %v，期望 {%s %s}, %q, %v",
+					tt.number, loc, prefix, ok, tt.province, tt.city, tt.prefix, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseLocationCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		prefixes  int
+		areaCodes int
+		wantErr   bool
+	}{
+		{"带表头和 BOM", "\ufeffprefix,province,city\n1380013,北京,北京\n0755,广东,深圳\n", 1, 1, false},
+		{"4~7 位号段", "1380,北京,北京\n13801,北京,北京\n138001,北京,北京\n", 3, 0, false},
+		{"多余的列忽略", " 1380013 , 北京 , 北京 ,备注\n", 1, 0, false},
+		{"8 位号段", "13800138,北京,北京\n", 0, 0, true},
+		{"3 位号段", "138,北京,北京\n", 0, 0, true},
+		{"5 位区号", "07551,广东,深圳\n", 0, 0, true},
+		{"缺少城市", "0755,广东\n", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := parseLocationCSV(strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if err == nil && (len(db.prefixes) != tt.prefixes || len(db.areaCodes) != tt.areaCodes) {
+				t.Errorf("号段/区号 = %d/%d，期望 %d/%d", len(db.prefixes), len(db.areaCodes), tt.prefixes, tt.areaCodes)
+			}
+		})
+	}
+}
+
+// 导入的库只含号段或只含区号时，另一部分沿用内置数据
+func TestSetLocationsKeepsBuiltin(t *testing.T) {
+	defer func() {
+		locationMu.Lock()
+		locations = nil
+		locationMu.Unlock()
+	}()
+
+	tests := []struct {
+		name   string
+		data   string
+		number string
+		want   Location
+	}{
+		{"只含号段时号段以导入的为准", "1380013,测试省,测试市\n", "13800138000", Location{"测试省", "测试市"}},
+		{"只含号段时沿用内置区号", "1380013,测试省,测试市\n", "075512345678", Location{"广东", "深圳"}},
+		{"只含区号时区号以导入的为准", "0755,测试省,测试市\n", "075512345678", Location{"测试省", "测试市"}},
+		{"只含区号时沿用内置号段", "0755,测试省,测试市\n", "8613800048000", Location{"辽宁", "沈阳"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := parseLocationCSV(strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			setLocations(db)
+			if loc, _, _ := IdentifyLocation(tt.number); loc != tt.want {
+				t.Errorf("IdentifyLocation(%q) = %v，期望 %v", tt.number, loc, tt.want)
+			}
+		})
+	}
+}
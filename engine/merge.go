@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeOptions 文件合并参数
+type MergeOptions struct {
+	Inputs []string // 按顺序合并的输入文件
+	Output string   // 输出文件路径
+	Dedup  bool     // 是否去除重复行
+
+	Normalize NormalizeOptions // 号码规范化规则，去重按规范化后的号码判断
+	Rejects   bool             // 按各国号码长度校验每一行，不合格的行不写入输出，记录到 输出_rejects.txt
+
+	MemoryBudget int64  // 去重内存预算（字节），估算超出时改用磁盘去重；0 使用默认值，负数始终使用内存
+	TempDir      string // 磁盘去重的临时目录，默认使用输出文件所在目录
+}
+
+// MergeResult 文件合并结果
+type MergeResult struct {
+	Output       string `json:"output"`
+	Files        int    `json:"files"`                  // 处理的文件数
+	LinesRead    int    `json:"lines_read"`             // 读取的非空行数
+	LinesWritten int    `json:"lines_written"`          // 写入的行数
+	Duplicates   int    `json:"duplicates"`             // 去重丢弃的行数
+	DiskDedup    bool   `json:"disk_dedup"`             // 是否使用了磁盘去重
+	Rejected     int    `json:"rejected"`               // 校验不合格的行数
+	RejectsFile  string `json:"rejects_file,omitempty"` // 不合格的行所在文件，没有时为空
+}
+
+// Merge 按顺序合并多个文件，去除空行，可选去重。
+// 取消或失败时删除未写完的输出文件。
+func Merge(ctx context.Context, opts MergeOptions, r Reporter) (result *MergeResult, err error) {
+	r = reporterOrNop(r)
+	if len(opts.Inputs) == 0 {
+		return nil, fmt.Errorf("没有要合并的文件")
+	}
+
+	// 删除可能存在的空文件
+	os.Remove(opts.Output)
+
+	outputs := &outputTracker{}
+	defer func() {
+		if err != nil {
+			outputs.removeAll()
+		}
+	}()
+
+	outputFile, err := outputs.create(opts.Output)
+	if err != nil {
+		return nil, fmt.Errorf("创建输出文件失败: %v", err)
+	}
+	defer outputFile.Close()
+
+	writer := bufio.NewWriter(outputFile)
+	defer writer.Flush()
+
+	rejects := newRejectWriter(opts.Rejects, outputs, rejectsPath(opts.Output))
+	defer rejects.close()
+
+	result = &MergeResult{Output: opts.Output}
+	totalFiles := len(opts.Inputs)
+	totalBytes := totalSize(opts.Inputs...)
+	meter := newProgressMeter(r, totalBytes)
+
+	write := func(line string) error {
+		if _, err := writer.WriteString(opts.Normalize.Apply(line) + "\n"); err != nil {
+			return fmt.Errorf("写入文件失败: %v", err)
+		}
+		result.LinesWritten++
+		return nil
+	}
+
+	var dedup lineDeduper
+	if opts.Dedup {
+		tempDir := opts.TempDir
+		if tempDir == "" {
+			tempDir = filepath.Dir(opts.Output)
+		}
+		if dedup, err = newLineDeduper(opts.Inputs, opts.MemoryBudget, tempDir, opts.Normalize.Key); err != nil {
+			return nil, err
+		}
+		defer dedup.close()
+		_, result.DiskDedup = dedup.(*spillDeduper)
+	}
+
+	for i, filePath := range opts.Inputs {
+		r.Status(fmt.Sprintf("🔄 处理文件 %d/%d: %s", i+1, totalFiles, filepath.Base(filePath)))
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("打开文件 %s 失败: %v", filePath, err)
+		}
+
+		scanner := newScanner(meter.track(file))
+		for lineNum := 1; scanner.Scan(); lineNum++ {
+			meter.line()
+			if lineNum%cancelCheckInterval == 0 {
+				if err := checkCanceled(ctx); err != nil {
+					file.Close()
+					return nil, err
+				}
+			}
+
+			line := strings.TrimSpace(scanner.Text())
+			key := opts.Normalize.Key(line)
+			if key == "" {
+				continue
+			}
+			result.LinesRead++
+
+			if rejected, err := rejects.check(filePath, lineNum, line, key); err != nil {
+				file.Close()
+				return nil, err
+			} else if rejected {
+				continue
+			}
+
+			if dedup != nil {
+				err = dedup.add(key, line, write)
+			} else {
+				err = write(line)
+			}
+			if err != nil {
+				file.Close()
+				return nil, err
+			}
+		}
+
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("读取文件 %s 失败: %v", filePath, err)
+		}
+		result.Files++
+	}
+
+	if dedup != nil {
+		if result.DiskDedup {
+			r.Status("🔄 正在归并磁盘去重结果...")
+		}
+		if err := dedup.finish(ctx, write); err != nil {
+			return nil, err
+		}
+	}
+
+	// 强制刷新缓冲区
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("刷新缓冲区失败: %v", err)
+	}
+	if result.Rejected, result.RejectsFile, err = rejects.close(); err != nil {
+		return nil, err
+	}
+	if dedup != nil {
+		result.Duplicates = result.LinesRead - result.Rejected - result.LinesWritten
+	}
+
+	meter.finish()
+	return result, nil
+}
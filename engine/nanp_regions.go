@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sync"
+)
+
+// 北美区号（NPA）对应的美国州和加拿大省，每行：区号,国家,州/省
+//
+//go:embed nanp_regions.csv
+var nanpRegionsCSV []byte
+
+type nanpRegion struct {
+	country string
+	region  string
+}
+
+var (
+	nanpOnce    sync.Once
+	nanpRegions map[string]nanpRegion
+)
+
+func loadNANPRegions() map[string]nanpRegion {
+	nanpOnce.Do(func() {
+		records, err := csv.NewReader(bytes.NewReader(nanpRegionsCSV)).ReadAll()
+		if err != nil {
+			panic(fmt.Sprintf("内置北美区号表格式错误: %v", err))
+		}
+		nanpRegions = make(map[string]nanpRegion, len(records))
+		for _, record := range records[1:] { // 跳过表头
+			nanpRegions[record[0]] = nanpRegion{country: record[1], region: record[2]}
+		}
+	})
+	return nanpRegions
+}
+
+// NANPRegion 根据北美号码（1 + 三位区号开头，不带 +）返回所属的美国州或加拿大省。
+// country 是区号所属的国家（美国或加拿大），不是美国或加拿大的区号返回 ok=false。
+func NANPRegion(number string) (country, region string, ok bool) {
+	if len(number) < 4 || number[0] != '1' {
+		return "", "", false
+	}
+	r, ok := loadNANPRegions()[number[1:4]]
+	return r.country, r.region, ok
+}
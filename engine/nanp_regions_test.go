@@ -0,0 +1,32 @@
+package engine
+
+import "testing"
+
+func TestNANPRegion(t *testing.T) {
+	tests := []struct {
+		number  string
+		country string
+		region  string
+		ok      bool
+	}{
+		{"12125551234", "美国", "纽约州", true},
+		{"13105551234", "美国", "加州", true},
+		{"12025551234", "美国", "华盛顿特区", true},
+		{"14165551234", "加拿大", "安大略省", true},
+		{"16045551234", "加拿大", "不列颠哥伦比亚省", true},
+		{"1212", "美国", "纽约州", true},
+		{"17875551234", "", "", false}, // 波多黎各不按州拆分
+		{"18005551234", "", "", false}, // 免费电话不属于任何州
+		{"121", "", "", false},
+		{"442071234567", "", "", false},
+		{"+12125551234", "", "", false}, // 需要先规范化
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			country, region, ok := NANPRegion(tt.number)
+			if country != tt.country || region != tt.region || ok != tt.ok {
+				t.Errorf("NANPRegion(%q) = %s, %s, %v，期望 %s, %s, %v", tt.number, country, region, ok, tt.country, tt.region, tt.ok)
+			}
+		})
+	}
+}
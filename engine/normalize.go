@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// OutputFormat 规范化后写入输出文件的号码格式
+type OutputFormat int
+
+const (
+	FormatOriginal OutputFormat = iota // 保持原样，写入第一次出现的原始行
+	FormatE164                         // E.164 格式，如 +8613800000000
+	FormatDigits                       // 纯数字，如 8613800000000
+)
+
+// OutputFormats 输出格式的显示名称，顺序与 OutputFormat 取值一致
+var OutputFormats = []string{"保持原样", "E.164（+国家码）", "纯数字"}
+
+// ParseOutputFormat 按名称解析输出格式，支持 original/e164/digits
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch strings.ToLower(name) {
+	case "", "original":
+		return FormatOriginal, nil
+	case "e164", "e.164":
+		return FormatE164, nil
+	case "digits":
+		return FormatDigits, nil
+	}
+	return FormatOriginal, fmt.Errorf("未知的输出格式: %s", name)
+}
+
+// NormalizeOptions 号码规范化规则。零值表示不做任何规范化。
+// 去重、比较、拆分和识别国家都使用规范化后的号码，
+// 因此 +8613800000000、008613800000000、86 138-0000-0000 会被视为同一个号码。
+type NormalizeOptions struct {
+	StripSeparators     bool         // 去除空格、横线、点和括号
+	InternationalPrefix bool         // 去除 + 和 00 国际前缀
+	FullWidth           bool         // 全角数字和符号转为半角
+	StripInvisible      bool         // 去除零宽字符等不可见字符
+	Format              OutputFormat // 输出格式
+}
+
+// DefaultNormalizeOptions 启用全部规则，输出保持原样
+func DefaultNormalizeOptions() NormalizeOptions {
+	return NormalizeOptions{
+		StripSeparators:     true,
+		InternationalPrefix: true,
+		FullWidth:           true,
+		StripInvisible:      true,
+	}
+}
+
+// Enabled 是否启用了任何规范化规则或输出格式
+func (o NormalizeOptions) Enabled() bool {
+	return o.StripSeparators || o.InternationalPrefix || o.FullWidth || o.StripInvisible || o.Format != FormatOriginal
+}
+
+// Key 返回规范化后的号码，用于去重、比较、前缀匹配和识别国家
+func (o NormalizeOptions) Key(line string) string {
+	if !o.Enabled() {
+		return line
+	}
+
+	var b strings.Builder
+	b.Grow(len(line))
+	for _, r := range line {
+		if o.FullWidth {
+			r = toHalfWidth(r)
+		}
+		if o.StripInvisible && isInvisible(r) {
+			continue
+		}
+		if o.StripSeparators && isSeparator(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	key := b.String()
+
+	if o.InternationalPrefix {
+		if strings.HasPrefix(key, "+") {
+			key = key[1:]
+		} else if strings.HasPrefix(key, "00") {
+			key = key[2:]
+		}
+	}
+	return key
+}
+
+// Output 按输出格式返回要写入的内容，key 为 Key(original) 的结果。
+// 规范化后不是纯数字的行无法转换格式，原样写入。
+func (o NormalizeOptions) Output(original, key string) string {
+	if o.Format == FormatOriginal {
+		return original
+	}
+	digits := strings.TrimPrefix(key, "+")
+	if !isDigits(digits) {
+		return original
+	}
+	if o.Format == FormatE164 {
+		return "+" + digits
+	}
+	return digits
+}
+
+// Apply 返回按输出格式转换后的行，保持原样时不做任何处理
+func (o NormalizeOptions) Apply(line string) string {
+	if o.Format == FormatOriginal {
+		return line
+	}
+	return o.Output(line, o.Key(line))
+}
+
+// 全角字符转半角：全角 ASCII 区间 U+FF01~U+FF5E，以及全角空格
+func toHalfWidth(r rune) rune {
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E:
+		return r - 0xFEE0
+	case r == 0x3000:
+		return ' '
+	}
+	return r
+}
+
+// 零宽空格、方向标记、BOM 等格式字符以及控制字符
+func isInvisible(r rune) bool {
+	return unicode.Is(unicode.Cf, r) || unicode.IsControl(r)
+}
+
+// 号码中常见的分隔符
+func isSeparator(r rune) bool {
+	switch r {
+	case '-', '.', '(', ')', '‐', '‑', '–', '—':
+		return true
+	}
+	return unicode.IsSpace(r)
+}
+
+// 是否为非空的纯数字
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
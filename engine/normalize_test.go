@@ -0,0 +1,90 @@
+package engine
+
+import "testing"
+
+func TestNormalizeKey(t *testing.T) {
+	defaults := DefaultNormalizeOptions()
+	tests := []struct {
+		name string
+		opts NormalizeOptions
+		line string
+		want string
+	}{
+		{"不规范化时原样返回", NormalizeOptions{}, "+86 138-0000-0000", "+86 138-0000-0000"},
+		{"纯数字", defaults, "8613800000000", "8613800000000"},
+		{"加号和分隔符", defaults, "+86 138-0000-0000", "8613800000000"},
+		{"00 前缀和点", defaults, "0086.138.0000.0000", "8613800000000"},
+		{"括号", defaults, "(86) 13800000000", "8613800000000"},
+		{"全角数字和加号", defaults, "＋８６１３８００００００００", "8613800000000"},
+		{"全角空格", defaults, "86\u3000138\u300000000000", "8613800000000"},
+		{"零宽字符和 BOM", defaults, "\ufeff86138\u200b00000000", "8613800000000"},
+		{"各种横线", defaults, "86\u2010138\u20110000\u20130000", "8613800000000"},
+		{"只去一次国际前缀", defaults, "+0086138", "0086138"},
+		{"分隔符之后的 00 前缀", defaults, "00 86 138", "86138"},
+		{"单个 0 不是国际前缀", defaults, "013800000000", "013800000000"},
+		{"非号码行", defaults, "备注 abc", "备注abc"},
+		{"只去国际前缀", NormalizeOptions{InternationalPrefix: true}, "+86 138", "86 138"},
+		{"只去分隔符", NormalizeOptions{StripSeparators: true}, "+86 138-0000", "+861380000"},
+		{"不转全角时全角空格仍是分隔符", NormalizeOptions{StripSeparators: true}, "86\u3000138", "86138"},
+		{"不转全角时全角数字保留", NormalizeOptions{StripSeparators: true}, "８６ 1", "８６1"},
+		{"不去不可见字符", NormalizeOptions{StripSeparators: true}, "86\u200b138", "86\u200b138"},
+		{"只设输出格式时号码不变", NormalizeOptions{Format: FormatDigits}, "+86 138", "+86 138"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Key(tt.line); got != tt.want {
+				t.Errorf("Key(%q) = %q，期望 %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		format OutputFormat
+		line   string
+		want   string
+	}{
+		{"保持原样", FormatOriginal, "+86 138-0000-0000", "+86 138-0000-0000"},
+		{"E.164", FormatE164, "+86 138-0000-0000", "+8613800000000"},
+		{"E.164 来自 00 前缀", FormatE164, "0086 13800000000", "+8613800000000"},
+		{"纯数字", FormatDigits, "+86 138-0000-0000", "8613800000000"},
+		{"纯数字来自全角", FormatDigits, "＋８６１３８", "86138"},
+		{"非号码行原样写入", FormatE164, "备注 abc", "备注 abc"},
+		{"规范化后为空时原样写入", FormatDigits, "---", "---"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultNormalizeOptions()
+			opts.Format = tt.format
+			if got := opts.Output(tt.line, opts.Key(tt.line)); got != tt.want {
+				t.Errorf("Output(%q) = %q，期望 %q", tt.line, got, tt.want)
+			}
+			if got := opts.Apply(tt.line); got != tt.want {
+				t.Errorf("Apply(%q) = %q，期望 %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		want OutputFormat
+		ok   bool
+	}{
+		{"", FormatOriginal, true},
+		{"original", FormatOriginal, true},
+		{"E164", FormatE164, true},
+		{"e.164", FormatE164, true},
+		{"digits", FormatDigits, true},
+		{"xml", FormatOriginal, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseOutputFormat(tt.name)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseOutputFormat(%q) = %v, %v，期望 %v", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NumberAddOptions 号码增加参数
+type NumberAddOptions struct {
+	Input       string // 要处理的文件
+	Output      string // 输出文件路径
+	Position    int    // 0 表示在开头增加，其他数字表示在第几位后增加
+	Digit       string // 要增加的字符，为空时随机生成 0-9
+	RemoveEmpty bool   // 是否去除空行
+	Rejects     bool   // 按各国号码长度校验每一行，不合格的行不处理也不写入输出，记录到 输出_rejects.txt
+}
+
+// NumberAddResult 号码增加结果
+type NumberAddResult struct {
+	Output       string `json:"output"`
+	LinesRead    int    `json:"lines_read"`    // 读取的行数
+	LinesWritten int    `json:"lines_written"` // 写入的行数
+	Rejected     int    `json:"rejected"`      // 校验不合格的行数
+
+	RejectsFile string `json:"rejects_file,omitempty"` // 不合格的行所在文件，没有时为空
+}
+
+// NumberAdd 在每行号码的指定位置增加字符（简化版，无去重功能）。
+// 取消或失败时删除未写完的输出文件。
+func NumberAdd(ctx context.Context, opts NumberAddOptions, r Reporter) (result *NumberAddResult, err error) {
+	r = reporterOrNop(r)
+	if opts.Position < 0 {
+		return nil, fmt.Errorf("请输入有效的位置数字（大于等于0的整数）")
+	}
+
+	// 删除已存在的输出文件
+	if _, err := os.Stat(opts.Output); err == nil {
+		os.Remove(opts.Output)
+	}
+
+	file, err := os.Open(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	outputs := &outputTracker{}
+	defer func() {
+		if err != nil {
+			outputs.removeAll()
+		}
+	}()
+
+	outputFile, err := outputs.create(opts.Output)
+	if err != nil {
+		return nil, fmt.Errorf("创建输出文件失败: %v", err)
+	}
+	defer outputFile.Close()
+
+	writer := bufio.NewWriter(outputFile)
+	defer writer.Flush()
+
+	rejects := newRejectWriter(opts.Rejects, outputs, rejectsPath(opts.Output))
+	defer rejects.close()
+
+	result = &NumberAddResult{Output: opts.Output}
+	meter := newProgressMeter(r, totalSize(opts.Input))
+	scanner := newScanner(meter.track(file))
+
+	// 逐行读取并处理
+	for scanner.Scan() {
+		line := scanner.Text()
+		result.LinesRead++
+		meter.line()
+
+		if result.LinesRead%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		empty := strings.TrimSpace(line) == ""
+
+		// 去除空行处理（如果勾选了去空选项）
+		if empty && opts.RemoveEmpty {
+			continue
+		}
+
+		// 空行原样写入，其余行校验合格后在指定位置增加字符（用户输入或随机）
+		if !empty {
+			if rejected, err := rejects.check(opts.Input, result.LinesRead, line, strings.TrimSpace(line)); err != nil {
+				return nil, err
+			} else if rejected {
+				continue
+			}
+			line = AddDigitAtPosition(line, opts.Position, opts.Digit)
+		}
+
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			return nil, fmt.Errorf("写入文件失败: %v", err)
+		}
+		result.LinesWritten++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	// 强制刷新缓冲区
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("刷新缓冲区失败: %v", err)
+	}
+	if result.Rejected, result.RejectsFile, err = rejects.close(); err != nil {
+		return nil, err
+	}
+
+	meter.finish()
+	return result, nil
+}
+
+// AddDigitAtPosition 在指定位置增加字符（用户输入或随机）
+func AddDigitAtPosition(line string, position int, userDigit string) string {
+	var charToAdd string
+
+	// 如果用户输入了字符，直接使用用户输入；否则使用随机数字
+	if userDigit != "" {
+		// 直接使用用户输入的任何字符
+		charToAdd = userDigit
+	} else {
+		// 用户未输入，生成随机数字（0-9）
+		charToAdd = strconv.Itoa(rand.Intn(10))
+	}
+
+	// 如果位置为0，在开头添加
+	if position == 0 {
+		return charToAdd + line
+	}
+
+	// 如果位置超过字符串长度，则在末尾添加
+	if position >= len(line) {
+		return line + charToAdd
+	}
+
+	// 在指定位置后插入字符
+	return line[:position] + charToAdd + line[position:]
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 各国号码长度规则，每行：前缀（含国家码）,国家码,国家码后最少位数,最多位数。
+// 按最长前缀匹配，如 86 为固话 10~11 位、8613 为手机 11 位、44 为 9~10 位、447 为手机 10 位。
+//
+//go:embed number_lengths.csv
+var numberLengthsCSV []byte
+
+// 号码长度规则
+type lengthRule struct {
+	code     string // 国家码
+	min, max int    // 国家码后的位数范围
+}
+
+var (
+	numberLengthOnce    sync.Once
+	numberLengthRules   []lengthRule
+	numberLengthMatcher *CountryMatcher // 复用前缀字典树，“国家”名为规则下标
+)
+
+func loadNumberLengths() *CountryMatcher {
+	numberLengthOnce.Do(func() {
+		records, err := csv.NewReader(bytes.NewReader(numberLengthsCSV)).ReadAll()
+		if err != nil {
+			panic(fmt.Sprintf("内置号码长度规则格式错误: %v", err))
+		}
+		var codes []CountryCode
+		for _, record := range records[1:] { // 跳过表头
+			min, err1 := strconv.Atoi(record[2])
+			max, err2 := strconv.Atoi(record[3])
+			if err1 != nil || err2 != nil || !strings.HasPrefix(record[0], record[1]) {
+				panic(fmt.Sprintf("内置号码长度规则格式错误: %v", record))
+			}
+			codes = append(codes, CountryCode{Name: strconv.Itoa(len(numberLengthRules)), Prefixes: []string{record[0]}})
+			numberLengthRules = append(numberLengthRules, lengthRule{code: record[1], min: min, max: max})
+		}
+		numberLengthMatcher = NewCountryMatcher(codes)
+	})
+	return numberLengthMatcher
+}
+
+// 完整的国际号码（含国家码）最多 15 位（E.164），少于 7 位的不可能是完整号码
+const (
+	minE164Digits = 7
+	maxE164Digits = 15
+)
+
+// ValidateNumber 按各国号码长度规则校验号码（含国家码，可带 +），合格时返回空字符串，否则返回原因。
+// 长度规则表中没有的国家只检查总位数是否在 7~15 位之间。
+func ValidateNumber(number string) string {
+	digits := strings.TrimPrefix(number, "+")
+	if digits == "" || !isDigits(digits) {
+		return "含有数字以外的字符"
+	}
+	if len(digits) < minE164Digits || len(digits) > maxE164Digits {
+		return fmt.Sprintf("长度不符：号码应为 %d~%d 位，实际 %d 位", minE164Digits, maxE164Digits, len(digits))
+	}
+
+	name, _ := loadNumberLengths().Match(digits)
+	i, err := strconv.Atoi(name)
+	if err != nil { // 没有匹配的长度规则
+		return ""
+	}
+	rule := numberLengthRules[i]
+	n := len(digits) - len(rule.code)
+	if n >= rule.min && n <= rule.max {
+		return ""
+	}
+	want := strconv.Itoa(rule.min)
+	if rule.max != rule.min {
+		want += "~" + strconv.Itoa(rule.max)
+	}
+	return fmt.Sprintf("长度不符：+%s 号码国家码后应为 %s 位，实际 %d 位", rule.code, want, n)
+}
+
+// 校验不合格的行写入的文件：输出_rejects.txt
+func rejectsPath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + "_rejects.txt"
+}
+
+// 校验每一行号码，不合格的行写入 _rejects.txt，每行：文件名:行号<TAB>原因<TAB>原始内容。
+// 有不合格的行时才创建文件。nil 表示不校验。
+type rejectWriter struct {
+	outputs *outputTracker
+	path    string
+	file    *os.File
+	writer  *bufio.Writer
+	count   int
+	closed  bool
+}
+
+// 不校验时返回 nil
+func newRejectWriter(enabled bool, outputs *outputTracker, path string) *rejectWriter {
+	if !enabled {
+		return nil
+	}
+	return &rejectWriter{outputs: outputs, path: path}
+}
+
+// 校验规范化后的号码 key，不合格时把原始行写入 _rejects.txt 并返回 true
+func (w *rejectWriter) check(source string, lineNum int, line, key string) (bool, error) {
+	if w == nil {
+		return false, nil
+	}
+	reason := ValidateNumber(key)
+	if reason == "" {
+		return false, nil
+	}
+	if w.file == nil {
+		file, err := w.outputs.create(w.path)
+		if err != nil {
+			return true, fmt.Errorf("创建文件 %s 失败: %v", w.path, err)
+		}
+		w.file = file
+		w.writer = bufio.NewWriter(file)
+	}
+	w.count++
+	if _, err := fmt.Fprintf(w.writer, "%s:%d\t%s\t%s\n", filepath.Base(source), lineNum, reason, line); err != nil {
+		return true, fmt.Errorf("写入文件 %s 失败: %v", w.path, err)
+	}
+	return true, nil
+}
+
+// 刷新并关闭文件，返回不合格的行数和文件路径（没有不合格的行时路径为空）。可重复调用。
+func (w *rejectWriter) close() (count int, path string, err error) {
+	if w == nil || w.file == nil {
+		return 0, "", nil
+	}
+	if !w.closed {
+		w.closed = true
+		err = w.writer.Flush()
+		if closeErr := w.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return w.count, w.path, fmt.Errorf("写入文件 %s 失败: %v", w.path, err)
+	}
+	return w.count, w.path, nil
+}
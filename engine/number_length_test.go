@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"8613800138000", ""},
+		{"+8613800138000", ""},
+		{"8675512345678", ""},
+		{"447700900123", ""},
+		{"12125551234", ""},
+		{"9771234567890", ""}, // 长度规则表中没有的国家只检查总位数
+		{"8613800138", "长度不符：+86 号码国家码后应为 11 位，实际 8 位"},
+		{"86138001380001", "长度不符：+86 号码国家码后应为 11 位，实际 12 位"},
+		{"4420712345", "长度不符：+44 号码国家码后应为 9~10 位，实际 8 位"},
+		{"123456", "长度不符：号码应为 7~15 位，实际 6 位"},
+		{"1234567890123456", "长度不符：号码应为 7~15 位，实际 16 位"},
+		{"86138-0013", "含有数字以外的字符"},
+		{"", "含有数字以外的字符"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if got := ValidateNumber(tt.number); got != tt.want {
+				t.Errorf("ValidateNumber(%q) = %q，期望 %q", tt.number, got, tt.want)
+			}
+		})
+	}
+}
+
+// 不合格的行写入 输出_rejects.txt，每行：文件名:行号<TAB>原因<TAB>原始内容；行号含空行
+func TestMergeRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  [][]string
+		written []string
+		rejects []string // 为空时不生成 _rejects.txt
+	}{
+		{
+			"多个文件",
+			[][]string{
+				{"8613800138000", "", "8613800138", "备注"},
+				{"+44 7700 900123", "4420712345"},
+			},
+			[]string{"8613800138000", "+44 7700 900123"},
+			[]string{
+				"in0.txt:3\t长度不符：+86 号码国家码后应为 11 位，实际 8 位\t8613800138",
+				"in0.txt:4\t含有数字以外的字符\t备注",
+				"in1.txt:2\t长度不符：+44 号码国家码后应为 9~10 位，实际 8 位\t4420712345",
+			},
+		},
+		{
+			"全部合格",
+			[][]string{{"8613800138000", "447700900123"}},
+			[]string{"8613800138000", "447700900123"},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var inputs []string
+			for i, lines := range tt.inputs {
+				inputs = append(inputs, writeTestFile(t, dir, fmt.Sprintf("in%d.txt", i), lines))
+			}
+			output := filepath.Join(dir, "out.txt")
+			result, err := Merge(context.Background(), MergeOptions{
+				Inputs:    inputs,
+				Output:    output,
+				Normalize: DefaultNormalizeOptions(),
+				Rejects:   true,
+			}, nil)
+			if err != nil {
+				t.Fatalf("合并失败: %v", err)
+			}
+			if got := readTestLines(t, output); !reflect.DeepEqual(got, tt.written) {
+				t.Errorf("输出 %v，期望 %v", got, tt.written)
+			}
+			if result.Rejected != len(tt.rejects) {
+				t.Errorf("不合格 %d 行，期望 %d 行", result.Rejected, len(tt.rejects))
+			}
+			if tt.rejects == nil {
+				if result.RejectsFile != "" {
+					t.Errorf("没有不合格的行时生成了 %s", result.RejectsFile)
+				}
+				return
+			}
+			if result.RejectsFile != filepath.Join(dir, "out_rejects.txt") {
+				t.Errorf("不合格的行写入 %s，期望 out_rejects.txt", result.RejectsFile)
+			}
+			if got := readTestLines(t, result.RejectsFile); !reflect.DeepEqual(got, tt.rejects) {
+				t.Errorf("_rejects.txt = %q，期望 %q", got, tt.rejects)
+			}
+		})
+	}
+}
+
+// 区号拆分的 _rejects.txt 以源文件名命名，写在输出目录；按归属地拆分时国内号码补上 86 后校验
+func TestCountrySplitRejects(t *testing.T) {
+	dir := t.TempDir()
+	input := writeTestFile(t, dir, "numbers.txt", []string{"13800138000", "1380013800", "075512345678", "0755123456"})
+	outputDir := filepath.Join(dir, "out")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatalf("创建输出目录失败: %v", err)
+	}
+	result, err := CountrySplit(context.Background(), CountrySplitOptions{
+		Input:     input,
+		OutputDir: outputDir,
+		Mode:      SplitByLocation,
+		Rejects:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("拆分失败: %v", err)
+	}
+	if result.LinesWritten != 2 || result.Rejected != 2 || result.InvalidLength != 0 {
+		t.Errorf("写入/不合格/长度异常 = %d/%d/%d，期望 2/2/0", result.LinesWritten, result.Rejected, result.InvalidLength)
+	}
+	want := []string{
+		"numbers.txt:2\t长度不符：+86 号码国家码后应为 11 位，实际 10 位\t1380013800",
+		"numbers.txt:4\t长度不符：+86 号码国家码后应为 10~11 位，实际 9 位\t0755123456",
+	}
+	if result.RejectsFile != filepath.Join(outputDir, "numbers_rejects.txt") {
+		t.Errorf("不合格的行写入 %s，期望输出目录下的 numbers_rejects.txt", result.RejectsFile)
+	}
+	if got := readTestLines(t, result.RejectsFile); !reflect.DeepEqual(got, want) {
+		t.Errorf("_rejects.txt = %q，期望 %q", got, want)
+	}
+}
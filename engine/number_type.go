@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 各国号码规划中号段对应的号码类型，每行：前缀（含国家码）,类型。
+// 按最长前缀匹配，如 44 为固话、447 为手机、44800 为免费电话。
+//
+//go:embed number_types.csv
+var numberTypesCSV []byte
+
+// NumberType 号码类型
+type NumberType int
+
+const (
+	TypeUnknown       NumberType = iota // 号码规划表中没有的国家或号段
+	TypeMobile                          // 手机
+	TypeFixed                           // 固话
+	TypeTollFree                        // 免费电话
+	TypePremium                         // 付费号码（声讯）
+	TypeVoIP                            // 网络电话、个人号码等不限地域的号码
+	TypeFixedOrMobile                   // 号码规划不区分固话和手机（如北美、墨西哥）
+)
+
+// NumberTypes 号码类型的显示名称（也用于输出文件名），顺序与 NumberType 取值一致
+var NumberTypes = []string{"未知类型", "手机", "固话", "免费电话", "付费号码", "网络电话", "固话或手机"}
+
+// 号码规划表中类型的写法，顺序与 NumberType 取值一致
+var numberTypeKeys = []string{"unknown", "mobile", "fixed", "tollfree", "premium", "voip", "fixed_or_mobile"}
+
+func (t NumberType) String() string {
+	return NumberTypes[t]
+}
+
+// MayBeMobile 是否可能是手机号（手机，或号码规划不区分固话和手机）
+func (t NumberType) MayBeMobile() bool {
+	return t == TypeMobile || t == TypeFixedOrMobile
+}
+
+var (
+	numberTypeOnce    sync.Once
+	numberTypeMatcher *CountryMatcher // 复用前缀字典树，“国家”名为类型下标
+)
+
+func loadNumberTypes() *CountryMatcher {
+	numberTypeOnce.Do(func() {
+		records, err := csv.NewReader(bytes.NewReader(numberTypesCSV)).ReadAll()
+		if err != nil {
+			panic(fmt.Sprintf("内置号码规划表格式错误: %v", err))
+		}
+		codes := make([]CountryCode, len(numberTypeKeys))
+		for i, key := range numberTypeKeys {
+			codes[i].Name = key
+		}
+		for _, record := range records[1:] { // 跳过表头
+			t := parseNumberTypeKey(record[1])
+			if t == TypeUnknown {
+				panic(fmt.Sprintf("内置号码规划表中有未知的类型: %s", record[1]))
+			}
+			codes[t].Prefixes = append(codes[t].Prefixes, record[0])
+		}
+		numberTypeMatcher = NewCountryMatcher(codes)
+	})
+	return numberTypeMatcher
+}
+
+func parseNumberTypeKey(key string) NumberType {
+	for i, k := range numberTypeKeys {
+		if k == key {
+			return NumberType(i)
+		}
+	}
+	return TypeUnknown
+}
+
+// ClassifyNumber 按号码规划判断号码类型，号码需含国家码（可带 +），如 447700900123
+func ClassifyNumber(number string) NumberType {
+	number = strings.TrimPrefix(number, "+")
+	key, _ := loadNumberTypes().Match(number)
+	return parseNumberTypeKey(key)
+}
+
+// TypeMode 按号码类型处理的方式
+type TypeMode int
+
+const (
+	TypeAll        TypeMode = iota // 不区分类型
+	TypeMobileOnly                 // 只保留可能是手机的号码，丢弃固话、免费电话等；无法判断类型的号码保留
+	TypeSplit                      // 每种类型分别输出
+)
+
+// TypeModes 处理方式的显示名称，顺序与 TypeMode 取值一致
+var TypeModes = []string{"不区分类型", "只保留手机号", "按类型分别输出"}
+
+// ParseTypeMode 按名称解析处理方式，支持 all/mobile/split
+func ParseTypeMode(name string) (TypeMode, error) {
+	switch strings.ToLower(name) {
+	case "", "all":
+		return TypeAll, nil
+	case "mobile":
+		return TypeMobileOnly, nil
+	case "split":
+		return TypeSplit, nil
+	}
+	return TypeAll, fmt.Errorf("未知的号码类型处理方式: %s", name)
+}
@@ -0,0 +1,30 @@
+package engine
+
+import "testing"
+
+func TestClassifyNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		want   NumberType
+	}{
+		{"8613800138000", TypeMobile},
+		{"+8613800138000", TypeMobile},
+		{"8675512345678", TypeFixed},
+		{"447700900123", TypeMobile},
+		{"442071234567", TypeFixed},
+		{"447012345678", TypeVoIP},
+		{"448001234567", TypeTollFree},
+		{"12125551234", TypeFixedOrMobile},
+		{"18005551234", TypeTollFree},
+		{"19005551234", TypePremium},
+		{"85221234567", TypeUnknown}, // 号码规划表中没有的国家
+		{"", TypeUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if got := ClassifyNumber(tt.number); got != tt.want {
+				t.Errorf("ClassifyNumber(%q) = %s，期望 %s", tt.number, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// 号码最多 15 位，这里放宽到 17 位：17 位十进制数小于 2^57，
+// 剩余高位用于记录前导零个数和 "+" 号，保证不同写法的号码编码不同。
+const maxNumberDigits = 17
+
+const (
+	numberPlusFlag   = 1 << 63 // 以 "+" 开头
+	numberZerosShift = 57      // 前导零个数存放在第 57~61 位
+)
+
+// 把纯数字行（可带 "+" 前缀）编码为 uint64，不是纯数字时返回 false。
+// 编码是可逆的一一对应，且结果总是非 0，0 可作为空槽位标记。
+func parseNumberKey(line string) (uint64, bool) {
+	var key uint64
+	if strings.HasPrefix(line, "+") {
+		key = numberPlusFlag
+		line = line[1:]
+	}
+	if line == "" || len(line) > maxNumberDigits {
+		return 0, false
+	}
+
+	zeros := 0
+	for zeros < len(line) && line[zeros] == '0' {
+		zeros++
+	}
+	var value uint64
+	for i := zeros; i < len(line); i++ {
+		c := line[i]
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		value = value*10 + uint64(c-'0')
+	}
+	return key | uint64(zeros)<<numberZerosShift | value, true
+}
+
+// 开放寻址的 uint64 集合，每个号码只占 8 字节（加上空槽位约 12~16 字节），
+// 远小于 map[string]bool 每个条目 60~80 字节的开销
+type numberSet struct {
+	slots []uint64 // 0 表示空槽位
+	count int
+}
+
+const numberSetMinSize = 1024
+
+// 添加号码，已存在时返回 false
+func (s *numberSet) add(key uint64) bool {
+	if (s.count+1)*4 > len(s.slots)*3 { // 装载率超过 75% 时扩容
+		s.grow()
+	}
+	mask := uint64(len(s.slots) - 1)
+	for i := hashNumber(key) & mask; ; i = (i + 1) & mask {
+		switch s.slots[i] {
+		case 0:
+			s.slots[i] = key
+			s.count++
+			return true
+		case key:
+			return false
+		}
+	}
+}
+
+// 判断号码是否存在
+func (s *numberSet) has(key uint64) bool {
+	if len(s.slots) == 0 {
+		return false
+	}
+	mask := uint64(len(s.slots) - 1)
+	for i := hashNumber(key) & mask; ; i = (i + 1) & mask {
+		switch s.slots[i] {
+		case 0:
+			return false
+		case key:
+			return true
+		}
+	}
+}
+
+func (s *numberSet) grow() {
+	size := len(s.slots) * 2
+	if size < numberSetMinSize {
+		size = numberSetMinSize
+	}
+	old := s.slots
+	s.slots = make([]uint64, size)
+	s.count = 0
+	for _, key := range old {
+		if key != 0 {
+			s.add(key)
+		}
+	}
+}
+
+// 乘法散列，打散连续号码
+func hashNumber(key uint64) uint64 {
+	key ^= key >> 33
+	key *= 0xff51afd7ed558ccd
+	key ^= key >> 33
+	return key
+}
+
+// 行集合：纯数字行存入 numberSet，其他行存入 map，两者互不冲突
+type lineSet struct {
+	numbers numberSet
+	others  map[string]bool
+}
+
+func newLineSet() *lineSet {
+	return &lineSet{others: make(map[string]bool)}
+}
+
+// 添加一行，已存在时返回 false
+func (s *lineSet) add(line string) bool {
+	if key, ok := parseNumberKey(line); ok {
+		return s.numbers.add(key)
+	}
+	if s.others[line] {
+		return false
+	}
+	s.others[line] = true
+	return true
+}
+
+// 判断行是否存在
+func (s *lineSet) has(line string) bool {
+	if key, ok := parseNumberKey(line); ok {
+		return s.numbers.has(key)
+	}
+	return s.others[line]
+}
+
+// 纯数字文件在内存中每字节输入大约占用的内存
+const numericMemoryFactor = 2
+
+// 抽样检查的字节数
+const numericSampleBytes = 64 * 1024
+
+// 抽样检查文件开头，判断是否为纯数字号码文件
+func looksNumeric(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	read := 0
+	found := false
+	for scanner.Scan() && read < numericSampleBytes {
+		read += len(scanner.Bytes()) + 1
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if _, ok := parseNumberKey(line); !ok {
+			return false
+		}
+		found = true
+	}
+	return found && scanner.Err() == nil
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseNumberKey(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+	}{
+		{"8613800000000", true},
+		{"+8613800000000", true},
+		{"0", true},
+		{"000", true},
+		{"+0", true},
+		{"99999999999999999", true},   // 17 位
+		{"+99999999999999999", true},  // "+" 不计入位数
+		{"00000000000000000", true},   // 17 个 0
+		{"999999999999999999", false}, // 18 位
+		{"", false},
+		{"+", false},
+		{"++1", false},
+		{"-1", false},
+		{"1+", false},
+		{" 1", false},
+		{"138 0000", false},
+		{"12a", false},
+		{"１２３", false}, // 全角数字
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, ok := parseNumberKey(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseNumberKey(%q) ok = %v，期望 %v", tt.line, ok, tt.ok)
+			}
+			if ok && key == 0 {
+				t.Errorf("parseNumberKey(%q) 编码为 0，与空槽位冲突", tt.line)
+			}
+		})
+	}
+}
+
+// 不同写法的号码编码必须不同，否则去重会丢行
+func TestParseNumberKeyDistinct(t *testing.T) {
+	lines := []string{
+		"0", "00", "000", "+0", "+00",
+		"1", "01", "001", "+1", "+01",
+		"86", "086", "0086", "+86", "+086",
+		"99999999999999999", "09999999999999999", "+99999999999999999",
+	}
+	seen := make(map[uint64]string)
+	for _, line := range lines {
+		key, ok := parseNumberKey(line)
+		if !ok {
+			t.Fatalf("parseNumberKey(%q) 失败", line)
+		}
+		if other, exists := seen[key]; exists {
+			t.Errorf("%q 与 %q 的编码相同", line, other)
+		}
+		seen[key] = line
+	}
+}
+
+func TestNumberSet(t *testing.T) {
+	var s numberSet
+	if s.has(1) {
+		t.Fatalf("空集合不应包含任何号码")
+	}
+
+	// 超过最小容量，覆盖多次扩容
+	const count = numberSetMinSize * 5
+	for i := 0; i < count; i++ {
+		key, _ := parseNumberKey(fmt.Sprintf("86138%08d", i))
+		if !s.add(key) {
+			t.Fatalf("第一次添加 %d 返回 false", i)
+		}
+	}
+	if s.count != count {
+		t.Fatalf("count = %d，期望 %d", s.count, count)
+	}
+	for i := 0; i < count; i++ {
+		key, _ := parseNumberKey(fmt.Sprintf("86138%08d", i))
+		if !s.has(key) {
+			t.Fatalf("扩容后找不到 %d", i)
+		}
+		if s.add(key) {
+			t.Fatalf("重复添加 %d 返回 true", i)
+		}
+	}
+	if key, _ := parseNumberKey("86139000000000"); s.has(key) {
+		t.Errorf("包含未添加的号码")
+	}
+	if s.count != count {
+		t.Errorf("重复添加后 count = %d，期望 %d", s.count, count)
+	}
+}
+
+// 纯数字行和其他行分开存放，互不影响
+func TestLineSet(t *testing.T) {
+	s := newLineSet()
+	steps := []struct {
+		line string
+		want bool
+	}{
+		{"8613800000000", true},
+		{"8613800000000", false},
+		{"+8613800000000", true},
+		{"08613800000000", true},
+		{"abc", true},
+		{"abc", false},
+		{"86 138", true},
+		{"", true},
+		{"", false},
+	}
+	for _, step := range steps {
+		if got := s.add(step.line); got != step.want {
+			t.Errorf("add(%q) = %v，期望 %v", step.line, got, step.want)
+		}
+		if !s.has(step.line) {
+			t.Errorf("has(%q) = false", step.line)
+		}
+	}
+	if s.has("8613800000001") || s.has("abcd") {
+		t.Errorf("包含未添加的行")
+	}
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrCanceled 操作被取消时返回，本次创建的输出文件已被删除
+var ErrCanceled = errors.New("操作已取消")
+
+// 每处理这么多行检查一次是否已取消
+const cancelCheckInterval = 4096
+
+// 检查操作是否已被取消
+func checkCanceled(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ErrCanceled
+	}
+	return nil
+}
+
+// 记录本次操作创建的输出文件，取消或失败时统一删除，避免留下写了一半的文件
+type outputTracker struct {
+	paths []string
+	dirs  []string // 本次新建的文件夹，删除文件后一并删除（只删除空文件夹）
+}
+
+// 创建输出文件并记录路径，已存在的文件会被覆盖
+func (t *outputTracker) create(path string) (*os.File, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	t.paths = append(t.paths, path)
+	return file, nil
+}
+
+// 记录由其他操作创建的输出文件
+func (t *outputTracker) add(paths ...string) {
+	t.paths = append(t.paths, paths...)
+}
+
+// 创建输出文件夹，新建的文件夹会被记录
+func (t *outputTracker) mkdir(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	t.dirs = append(t.dirs, dir)
+	return nil
+}
+
+// 删除已记录的所有输出文件和新建的空文件夹（调用前需先关闭文件）
+func (t *outputTracker) removeAll() {
+	for _, path := range t.paths {
+		os.Remove(path)
+	}
+	for i := len(t.dirs) - 1; i >= 0; i-- {
+		os.Remove(t.dirs[i])
+	}
+	t.paths, t.dirs = nil, nil
+}
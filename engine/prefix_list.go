@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ParsePrefixList 解析粘贴或从文件读取的前缀列表：每行一个，也可用逗号、分号或空白分隔，
+// # 开头的行为注释，重复的前缀只保留第一个
+func ParsePrefixList(text string) []string {
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimPrefix(text, "\ufeff"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == '，' || r == ';' || r == '；' || r == ' ' || r == '\t' || r == '\r' || r == '\u3000'
+		})
+		for _, prefix := range fields {
+			if !seen[prefix] {
+				seen[prefix] = true
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+	return prefixes
+}
+
+// LoadPrefixFile 从文本文件读取前缀列表，格式同 ParsePrefixList
+func LoadPrefixFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取前缀文件失败: %v", err)
+	}
+	return ParsePrefixList(string(data)), nil
+}
+
+// 前缀匹配：按前缀长度分组查表，每行只需查询不同长度的个数次，前缀再多也不变慢。
+// 多个前缀都匹配时取最长的，如 86 和 8613 同时存在时 8613… 归入 8613。
+type prefixMatcher struct {
+	prefixes []string       // 规范化后的前缀，顺序与输入一致
+	index    map[string]int // 前缀 -> 在 prefixes 中的下标
+	lengths  []int          // 出现过的前缀长度，从长到短
+}
+
+func newPrefixMatcher(prefixes []string) *prefixMatcher {
+	m := &prefixMatcher{index: make(map[string]int)}
+	seenLength := make(map[int]bool)
+	for _, prefix := range prefixes {
+		if _, ok := m.index[prefix]; ok || prefix == "" {
+			continue
+		}
+		m.index[prefix] = len(m.prefixes)
+		m.prefixes = append(m.prefixes, prefix)
+		if !seenLength[len(prefix)] {
+			seenLength[len(prefix)] = true
+			m.lengths = append(m.lengths, len(prefix))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(m.lengths)))
+	return m
+}
+
+// 返回 key 匹配到的最长前缀的下标，没有匹配时返回 -1
+func (m *prefixMatcher) match(key string) int {
+	for _, n := range m.lengths {
+		if len(key) < n {
+			continue
+		}
+		if i, ok := m.index[key[:n]]; ok {
+			return i
+		}
+	}
+	return -1
+}
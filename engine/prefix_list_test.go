@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrefixList(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"每行一个", "86\n44\n1\n", []string{"86", "44", "1"}},
+		{"各种分隔符", "86,44，1;7；33 34\t39\u300049\r\n", []string{"86", "44", "1", "7", "33", "34", "39", "49"}},
+		{"注释和空行", "# 中国\n86\n\n  # 英国\n44\n", []string{"86", "44"}},
+		{"重复的只保留第一个", "86\n44\n86\n", []string{"86", "44"}},
+		{"BOM", "\ufeff86\n", []string{"86"}},
+		{"空", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParsePrefixList(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePrefixList(%q) = %q，期望 %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixMatcher(t *testing.T) {
+	m := newPrefixMatcher([]string{"86", "8613", "", "44", "86", "1"})
+	if want := []string{"86", "8613", "44", "1"}; !reflect.DeepEqual(m.prefixes, want) {
+		t.Fatalf("prefixes = %q，期望 %q（去掉空前缀和重复前缀）", m.prefixes, want)
+	}
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"8613800138000", 1},
+		{"8675512345678", 0},
+		{"447700900123", 2},
+		{"12125551234", 3},
+		{"86", 0},
+		{"8", -1},
+		{"33123456789", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := m.match(tt.key); got != tt.want {
+			t.Errorf("match(%q) = %d，期望 %d", tt.key, got, tt.want)
+		}
+	}
+}
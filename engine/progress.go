@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Stats 一次进度更新的统计信息
+type Stats struct {
+	Fraction   float64       // 总体进度，0~1
+	Lines      int64         // 已处理行数
+	Bytes      int64         // 已读取字节数
+	TotalBytes int64         // 输入文件总字节数
+	Elapsed    time.Duration // 已用时间
+}
+
+// LinesPerSecond 平均每秒处理行数
+func (s Stats) LinesPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Lines) / s.Elapsed.Seconds()
+}
+
+// BytesPerSecond 平均每秒读取字节数
+func (s Stats) BytesPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Elapsed.Seconds()
+}
+
+// Remaining 按当前速度估算的剩余时间，无法估算时返回 -1
+func (s Stats) Remaining() time.Duration {
+	if s.Fraction <= 0 || s.Elapsed <= 0 {
+		return -1
+	}
+	if s.Fraction >= 1 {
+		return 0
+	}
+	return time.Duration(float64(s.Elapsed) * (1 - s.Fraction) / s.Fraction)
+}
+
+// 两次进度通知之间的最小间隔，避免大文件时频繁刷新界面
+const progressInterval = 200 * time.Millisecond
+
+// 每处理这么多行才检查一次时间，减少 time.Now 调用
+const progressCheckLines = 1024
+
+// 统计已读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// 按已读取字节数计算进度，并节流后通知 Reporter。
+// 当前阶段的进度映射到 [lo, hi] 区间，便于多阶段操作共用一个进度条。
+type progressMeter struct {
+	r      Reporter
+	start  time.Time
+	last   time.Time
+	total  int64           // 输入文件总字节数
+	base   int64           // 已读完的输入文件字节数
+	reader *countingReader // 当前正在读取的输入
+	lines  int64
+	lo, hi float64
+}
+
+func newProgressMeter(r Reporter, totalBytes int64) *progressMeter {
+	now := time.Now()
+	return &progressMeter{r: r, start: now, last: now, total: totalBytes, hi: 1}
+}
+
+// 输入文件的总字节数，无法获取大小的文件按 0 计算
+func totalSize(paths ...string) int64 {
+	var total int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// 开始读取新的输入，返回统计字节数的 Reader
+func (m *progressMeter) track(r io.Reader) io.Reader {
+	if m.reader != nil {
+		m.base += m.reader.n
+	}
+	m.reader = &countingReader{r: r}
+	return m.reader
+}
+
+// 进入新的阶段，之后的进度映射到 [lo, hi] 区间
+func (m *progressMeter) phase(lo, hi float64) {
+	m.lo, m.hi = lo, hi
+}
+
+// 已读取的总字节数
+func (m *progressMeter) bytes() int64 {
+	if m.reader == nil {
+		return m.base
+	}
+	return m.base + m.reader.n
+}
+
+// 处理完一行，按字节进度节流通知
+func (m *progressMeter) line() {
+	m.lines++
+	if m.lines%progressCheckLines != 0 {
+		return
+	}
+	if m.total > 0 {
+		m.emit(float64(m.bytes())/float64(m.total), false)
+	}
+}
+
+// 按阶段内进度（0~1）通知，用于不读取输入的阶段
+func (m *progressMeter) set(fraction float64) {
+	m.emit(fraction, false)
+}
+
+// 阶段结束，立即通知
+func (m *progressMeter) finish() {
+	m.emit(1, true)
+}
+
+func (m *progressMeter) emit(fraction float64, force bool) {
+	now := time.Now()
+	if !force && now.Sub(m.last) < progressInterval {
+		return
+	}
+	m.last = now
+
+	if fraction > 1 {
+		fraction = 1
+	}
+	m.r.Progress(Stats{
+		Fraction:   m.lo + (m.hi-m.lo)*fraction,
+		Lines:      m.lines,
+		Bytes:      m.bytes(),
+		TotalBytes: m.total,
+		Elapsed:    now.Sub(m.start),
+	})
+}
+
+// String 返回适合显示在进度条上的文字，如 "45% · 120000 行/秒 · 3.5 MB/秒 · 剩余 1分05秒"
+func (s Stats) String() string {
+	text := fmt.Sprintf("%.0f%%", s.Fraction*100)
+	if s.Elapsed < time.Second {
+		return text
+	}
+	text += fmt.Sprintf(" · %.0f 行/秒 · %.1f MB/秒", s.LinesPerSecond(), s.BytesPerSecond()/1024/1024)
+	if remaining := s.Remaining(); remaining > 0 {
+		text += " · 剩余 " + formatDuration(remaining)
+	} else if s.Fraction >= 1 {
+		text += " · 用时 " + formatDuration(s.Elapsed)
+	}
+	return text
+}
+
+// 格式化时长，如 1时02分03秒、2分05秒、8秒
+func formatDuration(d time.Duration) string {
+	seconds := int64(d.Round(time.Second) / time.Second)
+	switch {
+	case seconds >= 3600:
+		return fmt.Sprintf("%d时%02d分%02d秒", seconds/3600, seconds%3600/60, seconds%60)
+	case seconds >= 60:
+		return fmt.Sprintf("%d分%02d秒", seconds/60, seconds%60)
+	default:
+		return fmt.Sprintf("%d秒", seconds)
+	}
+}
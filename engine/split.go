@@ -0,0 +1,583 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SplitMode 拆分方式
+type SplitMode int
+
+const (
+	SplitByParts    SplitMode = iota // 按份数平均拆分，每份是连续的一段
+	SplitByLines                     // 每份最多 LinesPerPart 行
+	SplitBySize                      // 每份最多 MaxBytes 字节，按行切分不截断号码
+	SplitRoundRobin                  // 按份数轮流分配：第 1 行给第 1 份，第 2 行给第 2 份……
+	SplitShuffle                     // 按份数随机分配，各份行数与平均拆分相同，Seed 相同时结果相同
+	SplitWeighted                    // 按 Weights 比例交替分配，如 50/30/20
+	SplitStratified                  // 按份数分层分配：按 StratifyBy 分组，每组的号码按比例分到每一份
+	SplitHash                        // 按号码哈希分桶：同一号码（和 Salt）总是分到同一份，与行的位置和号码写法无关
+)
+
+// SplitModes 拆分方式的显示名称，顺序与 SplitMode 取值一致
+var SplitModes = []string{"按份数", "按每份行数", "按每份大小", "轮流分配", "随机分配", "按比例分配", "按分组均分", "按号码哈希分桶"}
+
+// ParseSplitMode 按名称解析拆分方式，支持 parts/lines/size/roundrobin/shuffle/weighted/stratified/hash
+func ParseSplitMode(name string) (SplitMode, error) {
+	switch strings.ToLower(name) {
+	case "", "parts":
+		return SplitByParts, nil
+	case "lines":
+		return SplitByLines, nil
+	case "size":
+		return SplitBySize, nil
+	case "roundrobin":
+		return SplitRoundRobin, nil
+	case "shuffle":
+		return SplitShuffle, nil
+	case "weighted":
+		return SplitWeighted, nil
+	case "stratified":
+		return SplitStratified, nil
+	case "hash":
+		return SplitHash, nil
+	}
+	return SplitByParts, fmt.Errorf("未知的拆分方式: %s", name)
+}
+
+// ParseWeights 解析各份的比例，如 50/30/20，也可用逗号或冒号分隔
+func ParseWeights(text string) ([]int, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '/' || r == ',' || r == '，' || r == ':' || r == '：' || r == ' '
+	})
+	weights := make([]int, 0, len(fields))
+	for _, field := range fields {
+		w, err := strconv.Atoi(strings.TrimSuffix(field, "%"))
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("无效的比例: %s（如 50/30/20）", text)
+		}
+		weights = append(weights, w)
+	}
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("请输入各份的比例，如 50/30/20")
+	}
+	return weights, nil
+}
+
+// SplitOptions 文件拆分参数
+type SplitOptions struct {
+	Input     string // 要拆分的文件
+	OutputDir string // 分片输出目录，为空时写在源文件旁边
+	Dedup     bool   // 是否去除重复行
+
+	Mode         SplitMode // 拆分方式，默认按份数
+	Parts        int       // 按份数、轮流、随机、分层分配或哈希分桶时的份数
+	LinesPerPart int       // 按行数拆分时每份最多的行数
+	MaxBytes     int64     // 按大小拆分时每份最多的字节数（含换行符），单行超出时单独成一份
+	Weights      []int     // 按比例分配时各份的比例
+	Seed         int64     // 随机分配的种子，0 表示自动生成（结果中返回实际使用的种子）
+	Salt         string    // 哈希分桶的盐，换盐后号码重新分桶；份数和盐不变时同一号码总在同一份
+
+	StratifyBy   StratifyKey   // 分层分配的分组依据，默认按国家区号
+	PrefixDigits int           // 按号码前缀分组时取规范化后号码的前几位
+	Countries    []CountryCode // 按国家分组时的区号表，为空时使用当前生效的区号表
+
+	Normalize NormalizeOptions // 号码规范化规则，去重按规范化后的号码判断
+	Rejects   bool             // 按各国号码长度校验每一行，不合格的行不写入分片，记录到分片旁边的 源文件名_rejects.txt
+
+	MemoryBudget int64  // 去重内存预算（字节），估算超出时改用磁盘去重；0 使用默认值，负数始终使用内存
+	TempDir      string // 磁盘去重和去重后唯一行的临时目录，默认使用分片所在目录
+}
+
+// SplitResult 文件拆分结果
+type SplitResult struct {
+	Outputs      []string `json:"outputs"`                // 按顺序生成的分片文件
+	LinesRead    int      `json:"lines_read"`             // 读取的非空行数
+	LinesWritten int      `json:"lines_written"`          // 写入分片的行数
+	Duplicates   int      `json:"duplicates"`             // 去重丢弃的行数
+	DiskDedup    bool     `json:"disk_dedup"`             // 是否使用了磁盘去重
+	Rejected     int      `json:"rejected"`               // 校验不合格的行数
+	RejectsFile  string   `json:"rejects_file,omitempty"` // 不合格的行所在文件，没有时为空
+	Seed         int64    `json:"seed,omitempty"`         // 随机分配实际使用的种子
+	PartLines    []int    `json:"part_lines"`             // 每份写入的行数，与 Outputs 对应
+
+	GroupBy string       `json:"group_by,omitempty"` // 分层分配的分组依据：国家、前缀或运营商
+	Groups  []SplitGroup `json:"groups,omitempty"`   // 分层分配时每组在各份中的行数，按行数从多到少排列
+}
+
+// Validate 检查拆分方式对应的数值是否有效
+func (opts SplitOptions) Validate() error {
+	switch opts.Mode {
+	case SplitByLines:
+		if opts.LinesPerPart <= 0 {
+			return fmt.Errorf("请输入有效的每份行数")
+		}
+	case SplitBySize:
+		if opts.MaxBytes <= 0 {
+			return fmt.Errorf("请输入有效的每份大小")
+		}
+	case SplitStratified:
+		if opts.Parts <= 0 {
+			return fmt.Errorf("请输入有效的拆分份数")
+		}
+		if opts.StratifyBy == StratifyByPrefix && opts.PrefixDigits <= 0 {
+			return fmt.Errorf("请输入有效的前缀位数")
+		}
+	case SplitWeighted:
+		if len(opts.Weights) == 0 {
+			return fmt.Errorf("请输入各份的比例，如 50/30/20")
+		}
+		for _, w := range opts.Weights {
+			if w <= 0 {
+				return fmt.Errorf("各份的比例必须大于 0")
+			}
+		}
+	default:
+		if opts.Parts <= 0 {
+			return fmt.Errorf("请输入有效的拆分份数")
+		}
+	}
+	return nil
+}
+
+// 是否需要先统计总行数：按份数平均拆分和随机分配要知道每份的行数
+func (opts SplitOptions) needsTotal() bool {
+	return opts.Mode == SplitByParts || opts.Mode == SplitShuffle
+}
+
+// 固定生成的份数，按行数或大小拆分时为 0（写完为止）
+func (opts SplitOptions) fixedParts() int {
+	switch opts.Mode {
+	case SplitByLines, SplitBySize:
+		return 0
+	case SplitWeighted:
+		return len(opts.Weights)
+	}
+	return opts.Parts
+}
+
+// 第 i 份最多写入的行数（负数为不限）和字节数（0 为不限）
+func (opts SplitOptions) partLimit(i, totalLines int) (count int, maxBytes int64) {
+	switch opts.Mode {
+	case SplitByLines:
+		return opts.LinesPerPart, 0
+	case SplitBySize:
+		return -1, opts.MaxBytes
+	}
+	count = totalLines / opts.Parts
+	if i < totalLines%opts.Parts {
+		count++
+	}
+	return count, 0
+}
+
+// Split 将文件按行拆分为若干份：按份数平均拆分，按每份行数、每份大小依次切分，
+// 或按份数轮流、随机、按比例、按分组（国家、前缀、运营商）均分、按号码哈希分桶到各份。
+// 边读边写，内存占用与文件大小无关：按份数平均拆分和随机分配时先统计行数（去重时同时把唯一行写入临时文件），
+// 其余方式只读一遍。分片写在源文件旁边或 OutputDir 中。取消或失败时删除本次已生成的分片。
+func Split(ctx context.Context, opts SplitOptions, r Reporter) (result *SplitResult, err error) {
+	r = reporterOrNop(r)
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(opts.Input); err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+
+	// 分片文件名：源文件名_part1.txt
+	base := strings.TrimSuffix(opts.Input, filepath.Ext(opts.Input))
+	if opts.OutputDir != "" {
+		base = filepath.Join(opts.OutputDir, filepath.Base(base))
+	}
+
+	result = &SplitResult{}
+	if opts.Mode == SplitShuffle {
+		if opts.Seed == 0 {
+			opts.Seed = time.Now().UnixNano()
+		}
+		result.Seed = opts.Seed
+	}
+	outputs := &outputTracker{}
+	parts := newPartWriter(opts, base, outputs, result)
+	defer func() {
+		if err != nil {
+			parts.buckets.abort()
+			outputs.removeAll()
+		}
+	}()
+	rejects := newRejectWriter(opts.Rejects, outputs, base+"_rejects.txt")
+	defer rejects.close()
+
+	tempDir := opts.TempDir
+	if tempDir == "" {
+		tempDir = filepath.Dir(base)
+	}
+	var dedup lineDeduper
+	if opts.Dedup {
+		if dedup, err = newLineDeduper([]string{opts.Input}, opts.MemoryBudget, tempDir, opts.Normalize.Key); err != nil {
+			return nil, err
+		}
+		defer dedup.close()
+		_, result.DiskDedup = dedup.(*spillDeduper)
+	}
+
+	// 需要先统计行数时读两遍，进度按两遍的总字节数计算
+	size := totalSize(opts.Input)
+	if opts.needsTotal() {
+		size *= 2
+	}
+	meter := newProgressMeter(r, size)
+	write := parts.write
+
+	if opts.needsTotal() {
+		// 第一遍统计要写入的行数，第二遍按每份的行数写入各分片
+		if dedup != nil {
+			// 去重时把唯一行写入临时文件，第二遍直接读临时文件
+			unique, err := os.CreateTemp(tempDir, "ts-merge-unique-*.txt")
+			if err != nil {
+				return nil, fmt.Errorf("创建临时文件失败: %v", err)
+			}
+			defer func() {
+				unique.Close()
+				os.Remove(unique.Name())
+			}()
+			writer := bufio.NewWriter(unique)
+			err = scanSplitInput(ctx, opts, r, meter, rejects, dedup, result, func(line string) error {
+				parts.total++
+				_, err := writer.WriteString(line + "\n")
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := writer.Flush(); err != nil {
+				return nil, fmt.Errorf("写入临时文件失败: %v", err)
+			}
+			if _, err := unique.Seek(0, 0); err != nil {
+				return nil, fmt.Errorf("读取临时文件失败: %v", err)
+			}
+			r.Status("🔄 正在写入分片文件...")
+			if err := copySplitLines(ctx, unique, meter, write); err != nil {
+				return nil, err
+			}
+		} else {
+			if parts.total, err = countSplitLines(ctx, opts, meter); err != nil {
+				return nil, err
+			}
+			r.Status("🔄 正在写入分片文件...")
+			if err := scanSplitInput(ctx, opts, r, meter, rejects, nil, result, write); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := scanSplitInput(ctx, opts, r, meter, rejects, dedup, result, write); err != nil {
+		return nil, err
+	}
+
+	if err := parts.close(); err != nil {
+		return nil, err
+	}
+	if result.Rejected, result.RejectsFile, err = rejects.close(); err != nil {
+		return nil, err
+	}
+	if dedup != nil {
+		result.Duplicates = result.LinesRead - result.Rejected - result.LinesWritten
+	}
+	if result.LinesWritten == 0 {
+		return nil, fmt.Errorf("文件为空或没有有效内容")
+	}
+
+	meter.finish()
+	return result, nil
+}
+
+// 逐行读取源文件，跳过空行和校验不合格的行，去重后把要写入的原始行交给 emit
+func scanSplitInput(ctx context.Context, opts SplitOptions, r Reporter, meter *progressMeter,
+	rejects *rejectWriter, dedup lineDeduper, result *SplitResult, emit func(string) error) error {
+	file, err := os.Open(opts.Input)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := newScanner(meter.track(file))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		meter.line()
+		if lineNum%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return err
+			}
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		key := opts.Normalize.Key(line)
+		if key == "" {
+			continue
+		}
+		result.LinesRead++
+
+		if rejected, err := rejects.check(opts.Input, lineNum, line, key); err != nil {
+			return err
+		} else if rejected {
+			continue
+		}
+
+		if dedup != nil {
+			err = dedup.add(key, line, emit)
+		} else {
+			err = emit(line)
+		}
+		if err != nil {
+			return fmt.Errorf("写入文件失败: %v", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	if dedup != nil {
+		if result.DiskDedup {
+			r.Status("🔄 正在归并磁盘去重结果...")
+		}
+		return dedup.finish(ctx, emit)
+	}
+	return nil
+}
+
+// 统计不去重时要写入分片的行数：非空且校验合格的行
+func countSplitLines(ctx context.Context, opts SplitOptions, meter *progressMeter) (int, error) {
+	file, err := os.Open(opts.Input)
+	if err != nil {
+		return 0, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	count := 0
+	scanner := newScanner(meter.track(file))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		meter.line()
+		if lineNum%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return 0, err
+			}
+		}
+		key := opts.Normalize.Key(strings.TrimSpace(scanner.Text()))
+		if key != "" && (!opts.Rejects || ValidateNumber(key) == "") {
+			count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return count, nil
+}
+
+// 把去重后临时文件中的行依次写入分片
+func copySplitLines(ctx context.Context, file *os.File, meter *progressMeter, write func(string) error) error {
+	scanner := newScanner(meter.track(file))
+	for n := 1; scanner.Scan(); n++ {
+		meter.line()
+		if n%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return err
+			}
+		}
+		if err := write(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取临时文件失败: %v", err)
+	}
+	return nil
+}
+
+// 按拆分方式决定每一行写入第几份：连续拆分时当前分片写满（达到行数或字节数上限）才换下一份，
+// 轮流、随机和按比例分配时每行单独选择
+type partWriter struct {
+	opts    SplitOptions
+	base    string // 分片文件名前缀，文件名为 base_part1.txt
+	outputs *outputTracker
+	buckets *bucketWriter
+	result  *SplitResult
+	total   int     // 需要先统计行数时，要写入的总行数
+	current int     // 连续拆分时的当前分片下标
+	lines   []int   // 每份已写入的行数
+	bytes   []int64 // 每份已写入的字节数（含换行符）
+	rng     *rand.Rand
+	credits []int       // 按比例分配时每份累计的配额
+	strata  *stratifier // 分层分配时每组在各份中的行数
+}
+
+func newPartWriter(opts SplitOptions, base string, outputs *outputTracker, result *SplitResult) *partWriter {
+	w := &partWriter{opts: opts, base: base, outputs: outputs, buckets: newBucketWriter(outputs, maxOpenBuckets), result: result}
+	switch opts.Mode {
+	case SplitShuffle:
+		w.rng = rand.New(rand.NewSource(opts.Seed))
+	case SplitWeighted:
+		w.credits = make([]int, len(opts.Weights))
+	case SplitStratified:
+		w.strata = newStratifier(opts)
+	}
+	return w
+}
+
+func (w *partWriter) path(i int) string {
+	return fmt.Sprintf("%s_part%d.txt", w.base, i+1)
+}
+
+// 写入一行（规范化前的原始行）
+func (w *partWriter) write(line string) error {
+	output := w.opts.Normalize.Apply(line)
+	i := w.choose(line, output)
+	for len(w.lines) <= i {
+		w.lines = append(w.lines, 0)
+		w.bytes = append(w.bytes, 0)
+	}
+	if err := w.buckets.write(w.path(i), output); err != nil {
+		return err
+	}
+	w.lines[i]++
+	w.bytes[i] += int64(len(output)) + 1
+	w.result.LinesWritten++
+	return nil
+}
+
+// 选择这一行写入的分片，line 为原始行，output 为写入的内容
+func (w *partWriter) choose(line, output string) int {
+	switch w.opts.Mode {
+	case SplitRoundRobin:
+		return w.result.LinesWritten % w.opts.Parts
+	case SplitShuffle:
+		return w.chooseRandom()
+	case SplitWeighted:
+		return w.chooseWeighted()
+	case SplitStratified:
+		return w.strata.choose(w.opts.Normalize.Key(line), w.lines)
+	case SplitHash:
+		// 不论是否开启规范化都按默认规则规范化后的号码分桶，同一号码的不同写法（+44、0044、44）分到同一份
+		return hashBucket(w.opts.Salt, DefaultNormalizeOptions().Key(line), w.opts.Parts)
+	}
+	for w.full(output) {
+		w.current++
+	}
+	return w.current
+}
+
+// 按各份剩余的行数加权随机选择，写完后各份行数正好与平均拆分相同
+func (w *partWriter) chooseRandom() int {
+	remaining := w.total - w.result.LinesWritten
+	if remaining <= 0 { // 不会发生：第二遍的行数与统计的相同
+		return w.opts.Parts - 1
+	}
+	n := w.rng.Intn(remaining)
+	for i := 0; i < w.opts.Parts; i++ {
+		count, _ := w.opts.partLimit(i, w.total)
+		if i < len(w.lines) {
+			count -= w.lines[i]
+		}
+		if n < count {
+			return i
+		}
+		n -= count
+	}
+	return w.opts.Parts - 1
+}
+
+// 号码所在的桶：对 盐 + 0 字节 + 规范化后的号码 计算 64 位 FNV-1a 哈希，再对份数取余。
+// 算法固定不变，号码列表增加新号码后重新拆分，原有号码仍在原来的份中。
+func hashBucket(salt, key string, parts int) int {
+	h := fnv.New64a()
+	h.Write([]byte(salt))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(parts))
+}
+
+// 平滑加权轮询：每行给累计配额最多的一份，各份交替出现，行数按比例分配
+func (w *partWriter) chooseWeighted() int {
+	sum, best := 0, 0
+	for i, weight := range w.opts.Weights {
+		w.credits[i] += weight
+		sum += weight
+		if w.credits[i] > w.credits[best] {
+			best = i
+		}
+	}
+	w.credits[best] -= sum
+	return best
+}
+
+// 连续拆分时当前分片是否已写满，写入 line 会超出上限；按大小拆分时每份至少写入一行
+func (w *partWriter) full(line string) bool {
+	if w.current >= len(w.lines) || w.opts.Mode == SplitByParts && w.current == w.opts.Parts-1 {
+		return false
+	}
+	count, maxBytes := w.opts.partLimit(w.current, w.total)
+	n := w.lines[w.current]
+	if count >= 0 && n >= count {
+		return true
+	}
+	return maxBytes > 0 && n > 0 && w.bytes[w.current]+int64(len(line))+1 > maxBytes
+}
+
+// 写完所有行后关闭分片文件；份数固定时行数不足的分片生成空文件
+func (w *partWriter) close() error {
+	if err := w.buckets.close(); err != nil {
+		return err
+	}
+	n := len(w.lines)
+	if fixed := w.opts.fixedParts(); fixed > 0 {
+		n = fixed
+	}
+	for i := 0; i < n; i++ {
+		path := w.path(i)
+		lines := 0
+		if i < len(w.lines) {
+			lines = w.lines[i]
+		}
+		if lines == 0 {
+			file, err := w.outputs.create(path)
+			if err != nil {
+				return fmt.Errorf("创建输出文件失败: %v", err)
+			}
+			file.Close()
+		}
+		w.result.Outputs = append(w.result.Outputs, path)
+		w.result.PartLines = append(w.result.PartLines, lines)
+	}
+	if w.strata != nil {
+		w.result.GroupBy = w.opts.StratifyBy.groupName()
+		w.result.Groups = w.strata.summary()
+	}
+	return nil
+}
+
+// 将行写入文件，已存在的文件会被覆盖
+func writeLines(ctx context.Context, outputs *outputTracker, filePath string, lines []string) error {
+	file, err := outputs.create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for i, line := range lines {
+		if i%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return err
+			}
+		}
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
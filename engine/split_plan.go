@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FileStats 文件的非空行数和字节数，用于拆分前预估份数
+type FileStats struct {
+	Lines int   `json:"lines"` // 非空行数
+	Bytes int64 `json:"bytes"` // 非空行（去除首尾空白）加换行符的字节数
+}
+
+// ScanFileStats 统计文件的非空行数和字节数
+func ScanFileStats(ctx context.Context, path string) (stats FileStats, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return stats, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := newScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if lineNum%cancelCheckInterval == 0 {
+			if err := checkCanceled(ctx); err != nil {
+				return stats, err
+			}
+		}
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			stats.Lines++
+			stats.Bytes += int64(len(line)) + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return stats, nil
+}
+
+// EstimateParts 按文件统计预估拆分生成的份数，拆分方式的数值无效时返回 0。
+// 未计入去重和校验丢弃的行；按大小拆分时按行切分，实际可能多一两份。
+func (opts SplitOptions) EstimateParts(stats FileStats) int {
+	if opts.Validate() != nil {
+		return 0
+	}
+	switch opts.Mode {
+	case SplitByLines:
+		return ceilDiv(int64(stats.Lines), int64(opts.LinesPerPart))
+	case SplitBySize:
+		return ceilDiv(stats.Bytes, opts.MaxBytes)
+	}
+	return opts.fixedParts()
+}
+
+func ceilDiv(a, b int64) int {
+	return int((a + b - 1) / b)
+}
+
+// 文件大小单位，按 1024 进位
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30}, {"G", 1 << 30},
+	{"MB", 1 << 20}, {"M", 1 << 20},
+	{"KB", 1 << 10}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize 解析文件大小，如 5MB、500KB、1.5G，不带单位时为字节数
+func ParseByteSize(text string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(text))
+	unit := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("无效的文件大小: %s（如 5MB、500KB）", text)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// FormatByteSize 将字节数格式化为便于阅读的大小，如 4.8 MB
+func FormatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
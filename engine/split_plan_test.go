@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+// 预估的份数与实际拆分生成的份数一致
+func TestEstimateParts(t *testing.T) {
+	dir := t.TempDir()
+	input := writeTestFile(t, dir, "in.txt", append(splitTestLines("44", 10), "", "  "))
+	stats, err := ScanFileStats(context.Background(), input)
+	if err != nil {
+		t.Fatalf("统计失败: %v", err)
+	}
+	if stats.Lines != 10 || stats.Bytes != 140 {
+		t.Fatalf("统计 = %+v，期望 10 行 140 字节", stats)
+	}
+
+	tests := []struct {
+		name string
+		opts SplitOptions
+		want int
+	}{
+		{"按份数", SplitOptions{Mode: SplitByParts, Parts: 3}, 3},
+		{"按每份行数", SplitOptions{Mode: SplitByLines, LinesPerPart: 4}, 3},
+		{"按每份行数整除", SplitOptions{Mode: SplitByLines, LinesPerPart: 5}, 2},
+		{"按每份大小", SplitOptions{Mode: SplitBySize, MaxBytes: 30}, 5},
+		{"份数无效", SplitOptions{Mode: SplitByParts}, 0},
+		{"行数无效", SplitOptions{Mode: SplitByLines, LinesPerPart: -1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.EstimateParts(stats); got != tt.want {
+				t.Errorf("EstimateParts = %d，期望 %d", got, tt.want)
+			}
+			if tt.want == 0 {
+				return
+			}
+			opts := tt.opts
+			opts.Input, opts.OutputDir = input, t.TempDir()
+			result, err := Split(context.Background(), opts, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+			if len(result.Outputs) != tt.want {
+				t.Errorf("实际生成 %d 份，预估 %d 份", len(result.Outputs), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		text string
+		want int64
+		ok   bool
+	}{
+		{"500", 500, true},
+		{"500B", 500, true},
+		{"500KB", 500 << 10, true},
+		{"5mb", 5 << 20, true},
+		{"1.5G", 3 << 29, true},
+		{" 2 M ", 2 << 20, true},
+		{"0", 0, false},
+		{"-1KB", 0, false},
+		{"MB", 0, false},
+		{"五兆", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.text)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseByteSize(%q) = %d, %v，期望 %d", tt.text, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StratifyKey 分层分配的分组依据
+type StratifyKey int
+
+const (
+	StratifyByCountry StratifyKey = iota // 按国家区号
+	StratifyByPrefix                     // 按号码前几位
+	StratifyByCarrier                    // 按中国大陆手机号段的运营商
+)
+
+// StratifyKeys 分组依据的显示名称，顺序与 StratifyKey 取值一致
+var StratifyKeys = []string{"国家区号", "号码前缀", "运营商（中国大陆号段）"}
+
+// ParseStratifyKey 按名称解析分组依据，支持 country/prefix/carrier
+func ParseStratifyKey(name string) (StratifyKey, error) {
+	switch strings.ToLower(name) {
+	case "", "country":
+		return StratifyByCountry, nil
+	case "prefix":
+		return StratifyByPrefix, nil
+	case "carrier":
+		return StratifyByCarrier, nil
+	}
+	return StratifyByCountry, fmt.Errorf("未知的分组依据: %s", name)
+}
+
+// 统计表第一列的名称
+func (k StratifyKey) groupName() string {
+	switch k {
+	case StratifyByPrefix:
+		return "前缀"
+	case StratifyByCarrier:
+		return "运营商"
+	}
+	return "国家"
+}
+
+// SplitGroup 分层分配时一个分组的行数
+type SplitGroup struct {
+	Name      string `json:"name"`       // 国家、前缀或运营商
+	Count     int    `json:"count"`      // 写入的行数
+	PartLines []int  `json:"part_lines"` // 在每一份中的行数
+}
+
+// 分层分配：每组的号码写入该组行数最少的一份，相同时写入总行数最少的一份。
+// 只读一遍，每组在各份中的行数最多相差 1，各份的总行数也基本相同。
+type stratifier struct {
+	opts    SplitOptions
+	matcher *CountryMatcher
+	groups  map[string]*SplitGroup
+}
+
+func newStratifier(opts SplitOptions) *stratifier {
+	s := &stratifier{opts: opts, groups: make(map[string]*SplitGroup)}
+	if opts.StratifyBy == StratifyByCountry {
+		s.matcher = activeCountryMatcher()
+		if len(opts.Countries) > 0 {
+			s.matcher = NewCountryMatcher(opts.Countries)
+		}
+	}
+	return s
+}
+
+// 规范化后的号码所属的分组
+func (s *stratifier) groupOf(key string) string {
+	switch s.opts.StratifyBy {
+	case StratifyByPrefix:
+		digits := strings.TrimPrefix(key, "+")
+		if len(digits) > s.opts.PrefixDigits {
+			digits = digits[:s.opts.PrefixDigits]
+		}
+		return digits
+	case StratifyByCarrier:
+		carrier, _ := IdentifyCarrier(key)
+		return carrier
+	}
+	return s.matcher.Identify(key)
+}
+
+// 选择号码写入的分片，lines 为各份已写入的行数（未写入的份可能不在其中）
+func (s *stratifier) choose(key string, lines []int) int {
+	name := s.groupOf(key)
+	group := s.groups[name]
+	if group == nil {
+		group = &SplitGroup{Name: name, PartLines: make([]int, s.opts.Parts)}
+		s.groups[name] = group
+	}
+	total := func(i int) int {
+		if i < len(lines) {
+			return lines[i]
+		}
+		return 0
+	}
+	best := 0
+	for i := 1; i < s.opts.Parts; i++ {
+		if n, b := group.PartLines[i], group.PartLines[best]; n < b || n == b && total(i) < total(best) {
+			best = i
+		}
+	}
+	group.Count++
+	group.PartLines[best]++
+	return best
+}
+
+// 按行数从多到少排列的分组统计
+func (s *stratifier) summary() []SplitGroup {
+	groups := make([]SplitGroup, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
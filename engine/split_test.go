@@ -0,0 +1,452 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// 生成 n 个不同的号码，每行 13 字节（写入分片后含换行符 14 字节）
+func splitTestLines(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%0*d", prefix, 13-len(prefix), i)
+	}
+	return lines
+}
+
+// 各拆分方式生成的份数和每份的行数
+func TestSplitPartLines(t *testing.T) {
+	ten := splitTestLines("44", 10)
+	mixed := append(splitTestLines("44", 6), splitTestLines("77", 4)...)
+
+	tests := []struct {
+		name  string
+		input []string
+		opts  SplitOptions
+		want  []int // 每份的行数
+	}{
+		{"按份数", ten, SplitOptions{Mode: SplitByParts, Parts: 3}, []int{4, 3, 3}},
+		{"份数多于行数", ten, SplitOptions{Mode: SplitByParts, Parts: 12}, []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0}},
+		{"按份数去重", append(ten, ten[:4]...), SplitOptions{Mode: SplitByParts, Parts: 3, Dedup: true}, []int{4, 3, 3}},
+		{"按每份行数去重", append(ten, ten...), SplitOptions{Mode: SplitByLines, LinesPerPart: 4, Dedup: true}, []int{4, 4, 2}},
+		{"按每份行数", ten, SplitOptions{Mode: SplitByLines, LinesPerPart: 4}, []int{4, 4, 2}},
+		{"按每份行数整除", ten, SplitOptions{Mode: SplitByLines, LinesPerPart: 5}, []int{5, 5}},
+		{"按每份大小", ten, SplitOptions{Mode: SplitBySize, MaxBytes: 30}, []int{2, 2, 2, 2, 2}},
+		{"单行超出大小", ten[:3], SplitOptions{Mode: SplitBySize, MaxBytes: 10}, []int{1, 1, 1}},
+		{"轮流分配", ten, SplitOptions{Mode: SplitRoundRobin, Parts: 3}, []int{4, 3, 3}},
+		{"随机分配", ten, SplitOptions{Mode: SplitShuffle, Parts: 3, Seed: 42}, []int{4, 3, 3}},
+		{"随机分配去重", append(ten, ten...), SplitOptions{Mode: SplitShuffle, Parts: 4, Seed: 7, Dedup: true}, []int{3, 3, 2, 2}},
+		{"按比例分配", ten, SplitOptions{Mode: SplitWeighted, Weights: []int{50, 30, 20}}, []int{5, 3, 2}},
+		{"按比例分配不整除", ten, SplitOptions{Mode: SplitWeighted, Weights: []int{1, 2}}, []int{3, 7}},
+		{"按前缀分组均分", mixed, SplitOptions{Mode: SplitStratified, Parts: 2, StratifyBy: StratifyByPrefix, PrefixDigits: 2}, []int{5, 5}},
+		{"按号码哈希分桶", ten, SplitOptions{Mode: SplitHash, Parts: 3}, []int{4, 3, 3}},
+		{"按国家分组均分", mixed, SplitOptions{Mode: SplitStratified, Parts: 3, Countries: []CountryCode{{"英国", []string{"44"}}, {"哈萨克斯坦", []string{"77"}}}}, []int{4, 3, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := tt.opts
+			opts.Input = writeTestFile(t, dir, "in.txt", tt.input)
+			opts.OutputDir = dir
+			if err := opts.Validate(); err != nil {
+				t.Fatalf("参数无效: %v", err)
+			}
+			result, err := Split(context.Background(), opts, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+
+			if !reflect.DeepEqual(result.PartLines, tt.want) {
+				t.Errorf("PartLines = %v，期望 %v", result.PartLines, tt.want)
+			}
+			if len(result.Outputs) != len(result.PartLines) {
+				t.Fatalf("Outputs 有 %d 个，PartLines 有 %d 个", len(result.Outputs), len(result.PartLines))
+			}
+
+			// 分片文件的行数与 PartLines 一致，合起来正好是去重后的输入
+			var written []string
+			for i, path := range result.Outputs {
+				lines := readTestLines(t, path)
+				if len(lines) != result.PartLines[i] {
+					t.Errorf("%s 有 %d 行，PartLines 为 %d", filepath.Base(path), len(lines), result.PartLines[i])
+				}
+				written = append(written, lines...)
+			}
+			want := uniqueTestLines(tt.input)
+			sort.Strings(written)
+			if !reflect.DeepEqual(written, want) {
+				t.Errorf("分片合起来有 %d 行，期望 %d 行", len(written), len(want))
+			}
+			if result.LinesWritten != len(want) {
+				t.Errorf("LinesWritten = %d，期望 %d", result.LinesWritten, len(want))
+			}
+
+			// 分层分配时每组在各份中的行数最多相差 1
+			for _, group := range result.Groups {
+				lo, hi := group.PartLines[0], group.PartLines[0]
+				for _, n := range group.PartLines {
+					if n < lo {
+						lo = n
+					}
+					if n > hi {
+						hi = n
+					}
+				}
+				if hi-lo > 1 {
+					t.Errorf("分组 %s 在各份中的行数 %v 相差超过 1", group.Name, group.PartLines)
+				}
+			}
+		})
+	}
+}
+
+// 排序后的唯一行
+func uniqueTestLines(lines []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, line := range lines {
+		if !seen[line] {
+			seen[line] = true
+			unique = append(unique, line)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// 分片默认写在源文件旁边，设置输出目录时写到输出目录，文件名都为 源文件名_part1.txt
+func TestSplitOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	input := writeTestFile(t, dir, "numbers.txt", splitTestLines("44", 5))
+	out := filepath.Join(dir, "out")
+	if err := os.MkdirAll(out, 0755); err != nil {
+		t.Fatalf("创建输出目录失败: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		outputDir string
+		want      []string
+	}{
+		{"源文件旁边", "", []string{filepath.Join(dir, "numbers_part1.txt"), filepath.Join(dir, "numbers_part2.txt")}},
+		{"输出目录", out, []string{filepath.Join(out, "numbers_part1.txt"), filepath.Join(out, "numbers_part2.txt")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Split(context.Background(), SplitOptions{Input: input, OutputDir: tt.outputDir, Mode: SplitByParts, Parts: 2}, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+			if !reflect.DeepEqual(result.Outputs, tt.want) {
+				t.Errorf("Outputs = %v，期望 %v", result.Outputs, tt.want)
+			}
+		})
+	}
+}
+
+// 磁盘去重与内存去重生成的分片逐字节一致
+func TestSplitDiskDedupMatchesMemory(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SplitOptions
+	}{
+		{"按份数", SplitOptions{Mode: SplitByParts, Parts: 3}},
+		{"按每份行数", SplitOptions{Mode: SplitByLines, LinesPerPart: 100}},
+		{"规范化后去重", SplitOptions{Mode: SplitByParts, Parts: 4, Normalize: DefaultNormalizeOptions()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := writeTestFile(t, dir, "in.txt", dedupTestLines(3000, 400, 0))
+
+			run := func(name string, budget int64) (*SplitResult, []string) {
+				opts := tt.opts
+				opts.Input, opts.OutputDir = input, filepath.Join(dir, name)
+				opts.Dedup, opts.MemoryBudget = true, budget
+				if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+					t.Fatalf("创建输出目录失败: %v", err)
+				}
+				result, err := Split(context.Background(), opts, nil)
+				if err != nil {
+					t.Fatalf("拆分失败: %v", err)
+				}
+				var parts []string
+				for _, path := range result.Outputs {
+					parts = append(parts, readTestFile(t, path))
+				}
+				return result, parts
+			}
+
+			memResult, memParts := run("memory", -1)
+			diskResult, diskParts := run("disk", totalSize(input))
+			if memResult.DiskDedup || !diskResult.DiskDedup {
+				t.Fatalf("DiskDedup = %v/%v，期望 false/true", memResult.DiskDedup, diskResult.DiskDedup)
+			}
+			if !reflect.DeepEqual(diskParts, memParts) {
+				t.Errorf("磁盘去重的分片与内存去重不同")
+			}
+			if diskResult.Duplicates != memResult.Duplicates || !reflect.DeepEqual(diskResult.PartLines, memResult.PartLines) {
+				t.Errorf("重复/每份行数 = %d/%v，期望 %d/%v",
+					diskResult.Duplicates, diskResult.PartLines, memResult.Duplicates, memResult.PartLines)
+			}
+		})
+	}
+}
+
+// 轮流分配按行的顺序依次写入各份
+func TestSplitRoundRobinOrder(t *testing.T) {
+	dir := t.TempDir()
+	lines := splitTestLines("44", 7)
+	result, err := Split(context.Background(), SplitOptions{
+		Input:     writeTestFile(t, dir, "in.txt", lines),
+		OutputDir: dir,
+		Mode:      SplitRoundRobin,
+		Parts:     3,
+	}, nil)
+	if err != nil {
+		t.Fatalf("拆分失败: %v", err)
+	}
+	want := [][]string{
+		{lines[0], lines[3], lines[6]},
+		{lines[1], lines[4]},
+		{lines[2], lines[5]},
+	}
+	for i, path := range result.Outputs {
+		if got := readTestLines(t, path); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("第 %d 份 = %v，期望 %v", i+1, got, want[i])
+		}
+	}
+}
+
+// 随机分配的种子相同时结果相同，不指定种子时返回实际使用的种子，用它可以重现结果
+func TestSplitShuffleSeed(t *testing.T) {
+	dir := t.TempDir()
+	input := writeTestFile(t, dir, "in.txt", splitTestLines("44", 200))
+
+	run := func(name string, seed int64) (*SplitResult, []string) {
+		outputDir := filepath.Join(dir, name)
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			t.Fatalf("创建输出目录失败: %v", err)
+		}
+		result, err := Split(context.Background(), SplitOptions{Input: input, OutputDir: outputDir, Mode: SplitShuffle, Parts: 4, Seed: seed}, nil)
+		if err != nil {
+			t.Fatalf("拆分失败: %v", err)
+		}
+		var parts []string
+		for _, path := range result.Outputs {
+			parts = append(parts, readTestFile(t, path))
+		}
+		return result, parts
+	}
+
+	auto, autoParts := run("auto", 0)
+	if auto.Seed == 0 {
+		t.Fatalf("没有返回实际使用的种子")
+	}
+	if _, again := run("again", auto.Seed); !reflect.DeepEqual(again, autoParts) {
+		t.Errorf("使用返回的种子 %d 没有重现结果", auto.Seed)
+	}
+	if _, other := run("other", auto.Seed+1); reflect.DeepEqual(other, autoParts) {
+		t.Errorf("不同的种子得到相同的结果")
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		text string
+		want []int
+		ok   bool
+	}{
+		{"50/30/20", []int{50, 30, 20}, true},
+		{"50%/30%/20%", []int{50, 30, 20}, true},
+		{"1，2，3", []int{1, 2, 3}, true},
+		{"3:1", []int{3, 1}, true},
+		{"1 1", []int{1, 1}, true},
+		{"", nil, false},
+		{"50/0", nil, false},
+		{"50/-1", nil, false},
+		{"a/b", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseWeights(tt.text)
+		if !reflect.DeepEqual(got, tt.want) || (err == nil) != tt.ok {
+			t.Errorf("ParseWeights(%q) = %v, %v，期望 %v", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+// 分层分配的分组统计：分组名称、行数和每组在各份中的行数，按行数从多到少排列
+func TestSplitStratifiedGroups(t *testing.T) {
+	carriers := []string{"13800138000", "13900139000", "13500135000", "18612345678", "18912345678", "18612345679", "13800138001", "12125551234"}
+	tests := []struct {
+		name    string
+		lines   []string
+		opts    SplitOptions
+		groupBy string
+		want    []SplitGroup
+	}{
+		{"按前缀", append(splitTestLines("44", 5), splitTestLines("77", 2)...),
+			SplitOptions{Parts: 2, StratifyBy: StratifyByPrefix, PrefixDigits: 2}, "前缀",
+			[]SplitGroup{{"44", 5, []int{3, 2}}, {"77", 2, []int{1, 1}}}},
+		{"按前缀位数多于号码", []string{"4412", "44", "4413"},
+			SplitOptions{Parts: 2, StratifyBy: StratifyByPrefix, PrefixDigits: 3}, "前缀",
+			[]SplitGroup{{"441", 2, []int{1, 1}}, {"44", 1, []int{0, 1}}}},
+		{"按运营商", carriers,
+			SplitOptions{Parts: 2, StratifyBy: StratifyByCarrier}, "运营商",
+			[]SplitGroup{{"中国移动", 4, []int{2, 2}}, {"中国联通", 2, []int{1, 1}}, {"中国电信", 1, []int{1, 0}}, {UnknownCarrier, 1, []int{0, 1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := tt.opts
+			opts.Mode = SplitStratified
+			opts.Input, opts.OutputDir = writeTestFile(t, dir, "in.txt", tt.lines), dir
+			result, err := Split(context.Background(), opts, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+			if result.GroupBy != tt.groupBy {
+				t.Errorf("GroupBy = %s，期望 %s", result.GroupBy, tt.groupBy)
+			}
+			if !reflect.DeepEqual(result.Groups, tt.want) {
+				t.Errorf("Groups = %v，期望 %v", result.Groups, tt.want)
+			}
+		})
+	}
+}
+
+// 哈希分桶的算法固定不变，升级后同一号码仍分到同一份
+func TestHashBucketFixed(t *testing.T) {
+	tests := []struct {
+		salt  string
+		key   string
+		parts int
+		want  int
+	}{
+		{"", "8613800000000", 10, 3},
+		{"", "8613800000001", 10, 2},
+		{"2024", "8613800000000", 10, 5},
+		{"", "447700900123", 7, 5},
+		{"salt", "447700900123", 7, 2},
+		{"", "12125551234", 1000, 236},
+		{"", "", 3, 0},
+	}
+	for _, tt := range tests {
+		if got := hashBucket(tt.salt, tt.key, tt.parts); got != tt.want {
+			t.Errorf("hashBucket(%q, %q, %d) = %d，期望 %d", tt.salt, tt.key, tt.parts, got, tt.want)
+		}
+	}
+}
+
+// 号码列表增加新号码后重新拆分，原有号码仍在原来的份中
+func TestSplitHashStable(t *testing.T) {
+	tests := []struct {
+		name    string
+		parts   int
+		salt    string
+		norm    NormalizeOptions
+		added   []string
+		prepend bool // 新号码插在原有号码前面
+	}{
+		{"追加新号码", 4, "", NormalizeOptions{}, splitTestLines("77", 200), false},
+		{"带盐", 5, "2024", NormalizeOptions{}, splitTestLines("77", 200), false},
+		{"新号码插在前面", 3, "", NormalizeOptions{}, splitTestLines("1", 300), true},
+		{"开启规范化", 4, "", DefaultNormalizeOptions(), []string{"+44 000-0000-0005", "0044 000 0000 0499"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			original := splitTestLines("44", 500)
+
+			// 返回每个号码所在的份
+			split := func(name string, lines []string) map[string]int {
+				opts := SplitOptions{
+					Input:     writeTestFile(t, dir, name+".txt", lines),
+					OutputDir: dir,
+					Mode:      SplitHash,
+					Parts:     tt.parts,
+					Salt:      tt.salt,
+					Normalize: tt.norm,
+				}
+				result, err := Split(context.Background(), opts, nil)
+				if err != nil {
+					t.Fatalf("拆分失败: %v", err)
+				}
+				parts := make(map[string]int)
+				for i, path := range result.Outputs {
+					for _, line := range readTestLines(t, path) {
+						parts[tt.norm.Key(line)] = i
+					}
+				}
+				return parts
+			}
+
+			before := split("before", original)
+			var grown []string
+			if tt.prepend {
+				grown = append(append(grown, tt.added...), original...)
+			} else {
+				grown = append(append(grown, original...), tt.added...)
+			}
+			after := split("after", grown)
+
+			for _, line := range original {
+				key := tt.norm.Key(line)
+				if after[key] != before[key] {
+					t.Fatalf("%s 从第 %d 份移到了第 %d 份", line, before[key]+1, after[key]+1)
+				}
+			}
+		})
+	}
+}
+
+// 同一号码的不同写法分到同一份，不论是否开启规范化
+func TestSplitHashSpellings(t *testing.T) {
+	var lines []string
+	for _, number := range splitTestLines("4479", 100) {
+		lines = append(lines, number, "+"+number, "00"+number, "+"+number[:2]+" "+number[2:6]+"-"+number[6:])
+	}
+	tests := []struct {
+		name string
+		norm NormalizeOptions
+	}{
+		{"不规范化", NormalizeOptions{}},
+		{"规范化", DefaultNormalizeOptions()},
+		{"只去分隔符", NormalizeOptions{StripSeparators: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			result, err := Split(context.Background(), SplitOptions{
+				Input:     writeTestFile(t, dir, "in.txt", lines),
+				OutputDir: dir,
+				Mode:      SplitHash,
+				Parts:     5,
+				Normalize: tt.norm,
+			}, nil)
+			if err != nil {
+				t.Fatalf("拆分失败: %v", err)
+			}
+			parts := make(map[string]int)
+			for i, path := range result.Outputs {
+				for _, line := range readTestLines(t, path) {
+					number := DefaultNormalizeOptions().Key(line)
+					if p, ok := parts[number]; ok && p != i {
+						t.Errorf("%s 的不同写法分到了第 %d 份和第 %d 份", number, p+1, i+1)
+					}
+					parts[number] = i
+				}
+			}
+			if len(parts) != 100 {
+				t.Errorf("共 %d 个号码，期望 100 个", len(parts))
+			}
+		})
+	}
+}
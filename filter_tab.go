@@ -1,10 +1,11 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -12,6 +13,8 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
 )
 
 // 创建文件过滤标签页
@@ -45,18 +48,27 @@ func (a *App) createFilterTab() *fyne.Container {
 		}
 	})
 
-	// 过滤参数 - 号码前缀输入框
-	a.filterPrefix1 = widget.NewEntry()
-	a.filterPrefix1.SetPlaceHolder("如：13")
-
-	a.filterPrefix2 = widget.NewEntry()
-	a.filterPrefix2.SetPlaceHolder("如：14")
+	// 过滤参数 - 号码前缀列表，数量不限
+	a.filterPrefixCount = widget.NewLabel("")
+	a.filterPrefixes = widget.NewMultiLineEntry()
+	a.filterPrefixes.SetPlaceHolder("每行一个前缀，也可用逗号分隔，如：\n13\n14\n8615")
+	a.filterPrefixes.SetMinRowsVisible(5)
+	a.filterPrefixes.OnChanged = func(text string) {
+		a.filterPrefixCount.SetText(fmt.Sprintf("共 %d 个前缀", len(engine.ParsePrefixList(text))))
+	}
+	a.filterPrefixes.OnChanged("")
+	importPrefixesBtn := widget.NewButton("📂 从文件导入", a.importFilterPrefixes)
+	clearPrefixesBtn := widget.NewButton("🧹 清空", func() { a.filterPrefixes.SetText("") })
 
-	a.filterPrefix3 = widget.NewEntry()
-	a.filterPrefix3.SetPlaceHolder("如：15")
+	a.filterExclude = widget.NewRadioGroup([]string{filterModeInclude, filterModeExclude}, nil)
+	a.filterExclude.Horizontal = true
+	a.filterExclude.Required = true
+	a.filterExclude.SetSelected(filterModeInclude)
+	a.filterWriteDropped = widget.NewCheck("📤 同时输出未保留的行（输出_dropped.txt）", nil)
+	a.filterResults = container.NewVBox()
 
-	a.filterPrefix4 = widget.NewEntry()
-	a.filterPrefix4.SetPlaceHolder("如：18")
+	a.filterTypes = newTypeModeSelect()
+	a.filterRejects = newRejectsCheck()
 
 	filterBtn := widget.NewButtonWithIcon("🔍 开始过滤", nil, func() {
 		if a.filterFile == "" {
@@ -68,7 +80,7 @@ func (a *App) createFilterTab() *fyne.Container {
 	filterBtn.Importance = widget.HighImportance
 
 	// 进度区域
-	a.filterProgress = widget.NewProgressBar()
+	a.filterProgress = newProgressBar()
 	a.filterStatus = widget.NewLabel("📋 就绪")
 	a.filterStatus.TextStyle = fyne.TextStyle{Italic: true}
 
@@ -85,22 +97,24 @@ func (a *App) createFilterTab() *fyne.Container {
 		selectFileBtn,
 		widget.NewSeparator(),
 		widget.NewLabel("⚙️ 号码前缀过滤设置:"),
-		widget.NewLabel("只保留以下前缀开头的号码行（空白输入框将被忽略）:"),
-		container.NewGridWithColumns(2,
-			widget.NewLabel("前缀1:"), a.filterPrefix1,
-			widget.NewLabel("前缀2:"), a.filterPrefix2,
-			widget.NewLabel("前缀3:"), a.filterPrefix3,
-			widget.NewLabel("前缀4:"), a.filterPrefix4,
-		),
+		widget.NewLabel("号码前缀（可粘贴任意多个，重复的前缀只算一次）:"),
+		a.filterPrefixes,
+		container.NewHBox(a.filterPrefixCount, importPrefixesBtn, clearPrefixesBtn),
+		container.NewHBox(widget.NewLabel("过滤方式:"), a.filterExclude),
+		a.filterWriteDropped,
+		container.NewHBox(widget.NewLabel("📱 号码类型（按号码规划识别，需含国家码）:"), a.filterTypes),
+		a.filterRejects,
+		a.filterNormalize.newWidget(a.window),
 	)
 
 	bottomSection := container.NewVBox(
 		widget.NewSeparator(),
-		container.NewHBox(widget.NewLabel(""), filterBtn),
+		container.NewHBox(widget.NewLabel(""), filterBtn, a.filterTask.newStopButton()),
 		widget.NewSeparator(),
 		widget.NewLabel("📊 进度状态:"),
 		a.filterProgress,
 		a.filterStatus,
+		a.filterResults,
 	)
 
 	return container.NewVBox(
@@ -171,44 +185,42 @@ func (a *App) startFilter() {
 		return
 	}
 
-	// 收集所有非空的前缀
-	var prefixes []string
-	if strings.TrimSpace(a.filterPrefix1.Text) != "" {
-		prefixes = append(prefixes, strings.TrimSpace(a.filterPrefix1.Text))
-	}
-	if strings.TrimSpace(a.filterPrefix2.Text) != "" {
-		prefixes = append(prefixes, strings.TrimSpace(a.filterPrefix2.Text))
-	}
-	if strings.TrimSpace(a.filterPrefix3.Text) != "" {
-		prefixes = append(prefixes, strings.TrimSpace(a.filterPrefix3.Text))
-	}
-	if strings.TrimSpace(a.filterPrefix4.Text) != "" {
-		prefixes = append(prefixes, strings.TrimSpace(a.filterPrefix4.Text))
+	prefixes := engine.ParsePrefixList(a.filterPrefixes.Text)
+	types := selectedTypeMode(a.filterTypes)
+	if len(prefixes) == 0 && (types == engine.TypeAll || a.filterExclude.Selected == filterModeExclude) {
+		dialog.ShowError(fmt.Errorf("请至少输入一个号码前缀"), a.window)
+		return
 	}
 
-	if len(prefixes) == 0 {
-		dialog.ShowError(fmt.Errorf("请至少输入一个号码前缀"), a.window)
+	ctx, ok := a.filterTask.begin()
+	if !ok {
+		dialog.ShowInformation("提示", "过滤任务正在进行中", a.window)
 		return
 	}
 
 	go func() {
+		defer a.filterTask.end()
 		a.filterStatus.SetText("🔄 正在过滤文件...")
-		a.filterProgress.SetValue(0)
+		resetProgress(a.filterProgress)
+		a.filterResults.RemoveAll()
 
-		err := a.performPrefixFilter(prefixes)
-		if err != nil {
+		summary, err := a.performPrefixFilter(ctx, prefixes, types)
+		if err == engine.ErrCanceled {
+			a.filterStatus.SetText("⏹ 已停止：未完成的输出文件已删除，源文件未改动")
+			return
+		} else if err != nil {
 			a.filterStatus.SetText("❌ 过滤失败: " + err.Error())
 			dialog.ShowError(err, a.window)
 		} else {
-			a.filterStatus.SetText("✅ 过滤完成")
-			dialog.ShowInformation("完成", "文件过滤成功！", a.window)
+			a.filterStatus.SetText("✅ 过滤完成：" + summary)
+			dialog.ShowInformation("完成", "文件过滤成功！\n"+summary, a.window)
 		}
 		a.filterProgress.SetValue(1.0)
 	}()
 }
 
-// 执行按前缀过滤操作
-func (a *App) performPrefixFilter(prefixes []string) error {
+// 执行按前缀过滤操作，返回结果摘要
+func (a *App) performPrefixFilter(ctx context.Context, prefixes []string, types engine.TypeMode) (string, error) {
 	// 使用 Windows 原生文件保存对话框
 	outputPath, err := nativeDialog.File().
 		Filter("文本文件", "txt").
@@ -216,7 +228,7 @@ func (a *App) performPrefixFilter(prefixes []string) error {
 		Save()
 
 	if err != nil {
-		return fmt.Errorf("保存对话框取消或失败: %v", err)
+		return "", fmt.Errorf("保存对话框取消或失败: %v", err)
 	}
 
 	// 确保输出文件有.txt扩展名
@@ -224,78 +236,99 @@ func (a *App) performPrefixFilter(prefixes []string) error {
 		outputPath += ".txt"
 	}
 
-	// 删除已存在的输出文件
-	if _, err := os.Stat(outputPath); err == nil {
-		os.Remove(outputPath)
-	}
-
-	file, err := os.Open(a.filterFile)
+	result, err := engine.Filter(ctx, engine.FilterOptions{
+		Input:        a.filterFile,
+		Output:       outputPath,
+		Prefixes:     prefixes,
+		Exclude:      a.filterExclude.Selected == filterModeExclude,
+		WriteDropped: a.filterWriteDropped.Checked,
+		Normalize:    a.filterNormalize.options(),
+		Types:        types,
+		Rejects:      a.filterRejects.Checked,
+	}, widgetReporter{a.filterProgress, a.filterStatus})
 	if err != nil {
-		return fmt.Errorf("打开文件失败: %v", err)
+		return "", err
+	}
+	if len(result.PrefixCounts) > 0 {
+		a.filterResults.Add(newPrefixCountsView(result))
 	}
-	defer file.Close()
 
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
+	fmt.Printf("✅ 过滤完成: 总行数 %d，保留行数 %d，输出文件: %s\n",
+		result.LinesRead, result.LinesKept, filepath.Base(outputPath))
+
+	summary := fmt.Sprintf("保留 %d 行，未保留 %d 行", result.LinesKept, result.LinesDropped)
+	if result.DroppedOutput != "" {
+		summary += fmt.Sprintf("（见 %s）", filepath.Base(result.DroppedOutput))
 	}
-	defer outputFile.Close()
-
-	writer := bufio.NewWriter(outputFile)
-	defer writer.Flush()
-
-	scanner := bufio.NewScanner(file)
-	// 设置更大的缓冲区以处理长行，避免 "token too long" 错误
-	buf := make([]byte, 0, 128*1024) // 128KB初始缓冲区
-	scanner.Buffer(buf, 2*1024*1024) // 2MB最大行长度
-
-	totalLines := 0
-	filteredLines := 0
-
-	// 逐行读取并过滤
-	for scanner.Scan() {
-		line := scanner.Text()
-		totalLines++
-
-		// 检查行是否以任何一个前缀开头
-		lineMatched := false
-		for _, prefix := range prefixes {
-			if strings.HasPrefix(line, prefix) {
-				lineMatched = true
-				break
-			}
+	switch types {
+	case engine.TypeMobileOnly:
+		summary += fmt.Sprintf("，丢弃非手机号 %d 行", result.NonMobile)
+		if result.UnknownType > 0 {
+			summary += fmt.Sprintf("，无法判断类型 %d 行（已保留）", result.UnknownType)
 		}
-
-		// 如果匹配任何前缀，则保留这一行
-		if lineMatched {
-			_, err := writer.WriteString(line + "\n")
-			if err != nil {
-				return fmt.Errorf("写入文件失败: %v", err)
-			}
-			filteredLines++
+	case engine.TypeSplit:
+		var parts []string
+		for name, count := range result.TypeCounts {
+			parts = append(parts, fmt.Sprintf("%s %d", name, count))
 		}
+		sort.Strings(parts)
+		summary += "（" + strings.Join(parts, "，") + "）"
+	}
+	return summary + rejectsNote(a.filterRejects.Checked, result.Rejected, result.RejectsFile), nil
+}
 
-		// 更新进度
-		if totalLines%1000 == 0 {
-			progress := float64(totalLines) / 100000.0 // 假设最大10万行
-			if progress > 1.0 {
-				progress = 1.0
-			}
-			a.filterProgress.SetValue(progress)
+// 过滤方式
+const (
+	filterModeInclude = "保留匹配的行"
+	filterModeExclude = "排除匹配的行"
+)
+
+// 从文本文件导入前缀（每行一个），追加到前缀列表
+func (a *App) importFilterPrefixes() {
+	file, err := nativeDialog.File().Filter("文本文件", "txt", "csv").Title("选择前缀列表文件").Load()
+	if err != nil {
+		if err.Error() != "Cancelled" {
+			dialog.ShowError(err, a.window)
 		}
+		return
 	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
+	prefixes, err := engine.LoadPrefixFile(file)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
 	}
-
-	// 强制刷新缓冲区
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("刷新缓冲区失败: %v", err)
+	text := strings.TrimSpace(a.filterPrefixes.Text)
+	if text != "" {
+		text += "\n"
 	}
+	a.filterPrefixes.SetText(text + strings.Join(prefixes, "\n"))
+	fmt.Printf("✅ 导入前缀 %d 个: %s\n", len(prefixes), filepath.Base(file))
+}
 
-	fmt.Printf("✅ 过滤完成: 总行数 %d，保留行数 %d，输出文件: %s\n",
-		totalLines, filteredLines, filepath.Base(outputPath))
-
-	return nil
+// 每个前缀匹配的行数，多个前缀都匹配时只计入最长的
+func newPrefixCountsView(result *engine.FilterResult) fyne.CanvasObject {
+	rows := [][]string{{"前缀", "匹配行数"}}
+	total := 0
+	for _, p := range result.PrefixCounts {
+		rows = append(rows, []string{p.Prefix, strconv.Itoa(p.Count)})
+		total += p.Count
+	}
+	rows = append(rows, []string{"合计", strconv.Itoa(total)})
+
+	table := widget.NewTable(
+		func() (int, int) { return len(rows), 2 },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			label.SetText(rows[id.Row][id.Col])
+			label.TextStyle = fyne.TextStyle{Bold: id.Row == 0 || id.Row == len(rows)-1}
+			label.Refresh()
+		},
+	)
+	table.SetColumnWidth(0, 180)
+	table.SetColumnWidth(1, 120)
+	return container.NewVBox(
+		widget.NewLabel("📈 各前缀匹配行数（同时匹配多个前缀时计入最长的）:"),
+		container.NewGridWrap(fyne.NewSize(400, 240), table),
+	)
 }
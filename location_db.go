@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/dialog"
+	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
+)
+
+// 启动时加载程序旁边的归属地库，失败时使用内置库
+func loadLocations() {
+	path, count, err := engine.LoadLocations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ 归属地库加载失败，使用内置归属地库: %v\n", err)
+		return
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "✅ 已加载归属地库: %s（%d 个号段）\n", path, count)
+	}
+}
+
+// 归属地库状态说明
+func locationInfoText() string {
+	return fmt.Sprintf("📍 归属地库: %d 个号段，%d 个固话区号", engine.LocationCount(), engine.AreaCodeCount())
+}
+
+// 导入新的归属地库，保存到程序旁边，下次启动自动加载
+func (a *App) importLocations() {
+	path, err := nativeDialog.File().Filter("归属地库", "csv", "txt").Title("导入归属地库").Load()
+	if err != nil {
+		return
+	}
+	count, err := engine.ImportLocations(path)
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+	a.countrySplitLocationInfo.SetText(locationInfoText())
+	fmt.Printf("✅ 已导入归属地库: %s（%d 个号段）\n", filepath.Base(path), count)
+	dialog.ShowInformation("导入成功", fmt.Sprintf("已导入 %d 个号段\n已保存为 %s，下次启动自动加载", count, engine.LocationsPath()), a.window)
+}
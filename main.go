@@ -17,8 +17,8 @@ var (
 	version = "1.0.0" // 构建时会被替换
 )
 
-func init() {
-	// 设置中文字体：解决中文乱码问题
+// 设置中文字体：解决中文乱码问题
+func setupChineseFont() {
 	fontPaths := findfont.List()
 	for _, path := range fontPaths {
 		if strings.Contains(path, "msyh.ttf") || // 微软雅黑
@@ -39,43 +39,77 @@ type App struct {
 	tabs   *container.AppTabs // 添加标签页引用
 
 	// 合并相关
-	mergeFiles    []string
-	mergeList     *widget.List
-	mergeDedup    *widget.Check
-	mergeProgress *widget.ProgressBar
-	mergeStatus   *widget.Label
+	mergeFiles     []string
+	mergeList      *widget.List
+	mergeDedup     *widget.Check
+	mergeRejects   *widget.Check
+	mergeNormalize normalizeControl
+	mergeProgress  *progressBar
+	mergeStatus    *widget.Label
+	mergeTask      taskControl
 
 	// 拆分相关
-	splitFile      string
-	splitFileLabel *widget.Label
-	splitParts     *widget.Entry
-	splitDedup     *widget.Check
-	splitProgress  *widget.ProgressBar
-	splitStatus    *widget.Label
+	splitFile         string
+	splitFileLabel    *widget.Label
+	splitMode         *widget.Select
+	splitParts        *widget.Entry  // 份数、每份行数或每份大小，含义取决于拆分方式
+	splitSeed         *widget.Entry  // 随机分配的种子，留空自动生成
+	splitSalt         *widget.Entry  // 哈希分桶的盐
+	splitStratifyBy   *widget.Select // 按分组均分的分组依据
+	splitPrefixDigits *widget.Entry  // 按号码前缀分组时取前几位
+	splitPreview      splitPreview
+	splitDedup        *widget.Check
+	splitOutputDir    *widget.Check // 输出到其他文件夹，开始拆分时选择
+	splitRejects      *widget.Check
+	splitNormalize    normalizeControl
+	splitProgress     *progressBar
+	splitStatus       *widget.Label
+	splitTask         taskControl
+	splitResults      *fyne.Container // 按分组均分后每组在各份中的行数
 
 	// 过滤相关
-	filterFile      string
-	filterFileLabel *widget.Label
-	filterPrefix1   *widget.Entry // 第一个前缀输入框
-	filterPrefix2   *widget.Entry // 第二个前缀输入框
-	filterPrefix3   *widget.Entry // 第三个前缀输入框
-	filterPrefix4   *widget.Entry // 第四个前缀输入框
-	filterProgress  *widget.ProgressBar
-	filterStatus    *widget.Label
+	filterFile         string
+	filterFileLabel    *widget.Label
+	filterPrefixes     *widget.Entry      // 前缀列表，每行一个，可粘贴或从文件导入
+	filterPrefixCount  *widget.Label      // 前缀个数
+	filterExclude      *widget.RadioGroup // 保留或排除匹配的行
+	filterWriteDropped *widget.Check      // 同时输出未保留的行
+	filterResults      *fyne.Container    // 每个前缀匹配的行数
+	filterNormalize    normalizeControl
+	filterTypes        *widget.Select // 号码类型处理方式
+	filterRejects      *widget.Check
+	filterProgress     *progressBar
+	filterStatus       *widget.Label
+	filterTask         taskControl
 
 	// 文件重复比较相关
 	compareFile1      string
 	compareFile1Label *widget.Label
 	compareFile2      string
 	compareFile2Label *widget.Label
-	compareProgress   *widget.ProgressBar
+	compareNormalize  normalizeControl
+	compareRejects    *widget.Check
+	compareProgress   *progressBar
 	compareStatus     *widget.Label
+	compareTask       taskControl
 
 	// 区号拆分相关
-	countrySplitFile      string
-	countrySplitFileLabel *widget.Label
-	countrySplitProgress  *widget.ProgressBar
-	countrySplitStatus    *widget.Label
+	countrySplitFile         string
+	countrySplitFileLabel    *widget.Label
+	countrySplitMode         *widget.RadioGroup
+	countrySplitDedup        *widget.Check
+	countrySplitTypes        *widget.Select
+	countrySplitRegions      *widget.Check
+	countrySplitByCity       *widget.Check
+	countrySplitRejects      *widget.Check
+	countrySplitLocationInfo *widget.Label
+	countrySplitSubMode      *widget.Select // 每个国家再拆分的方式
+	countrySplitSubValue     *widget.Entry  // 再拆分的份数或每份行数
+	countrySplitNormalize    normalizeControl
+	countrySplitResults      *fyne.Container
+	countrySplitProgress     *progressBar
+	countrySplitStatus       *widget.Label
+	countrySplitTask         taskControl
 
 	// 号码增加相关
 	numberAddFile        string
@@ -83,14 +117,30 @@ type App struct {
 	numberAddPosition    *widget.Entry
 	numberAddDigit       *widget.Entry // 新增：用户输入要增加的数字
 	numberAddRemoveEmpty *widget.Check
-	numberAddProgress    *widget.ProgressBar
+	numberAddRejects     *widget.Check
+	numberAddProgress    *progressBar
 	numberAddStatus      *widget.Label
+	numberAddTask        taskControl
 }
 
 func main() {
 	// 设置运行时参数以优化大文件处理
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	// 带参数启动时进入命令行模式，不创建窗口
+	cliMode := len(os.Args) > 1
+	if cliMode {
+		attachConsole()
+	}
+
+	loadCountryRules()
+	loadLocations()
+
+	if cliMode {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
+	setupChineseFont()
 	myApp := app.New()
 
 	// 设置应用程序图标
@@ -1,9 +1,8 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -12,6 +11,8 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
 )
 
 // 创建文件合并标签页
@@ -52,6 +53,7 @@ func (a *App) createMergeTab() *fyne.Container {
 
 	// 选项区域
 	a.mergeDedup = widget.NewCheck("🔄 去除重复行", nil)
+	a.mergeRejects = newRejectsCheck()
 	mergeBtn := widget.NewButtonWithIcon("🚀 开始合并", nil, func() {
 		if len(a.mergeFiles) == 0 {
 			dialog.ShowInformation("提示", "请先选择要合并的文件", a.window)
@@ -62,7 +64,7 @@ func (a *App) createMergeTab() *fyne.Container {
 	mergeBtn.Importance = widget.HighImportance
 
 	// 进度区域
-	a.mergeProgress = widget.NewProgressBar()
+	a.mergeProgress = newProgressBar()
 	a.mergeStatus = widget.NewLabel("📋 就绪")
 	a.mergeStatus.TextStyle = fyne.TextStyle{Italic: true}
 
@@ -90,8 +92,11 @@ func (a *App) createMergeTab() *fyne.Container {
 	rightSection := container.NewVBox(
 		widget.NewLabel("⚙️ 合并选项:"),
 		a.mergeDedup,
+		a.mergeRejects,
+		a.mergeNormalize.newWidget(a.window),
 		widget.NewSeparator(),
 		mergeBtn,
+		a.mergeTask.newStopButton(),
 		widget.NewSeparator(),
 		widget.NewLabel("📊 进度状态:"),
 		a.mergeProgress,
@@ -222,9 +227,16 @@ func (a *App) startMerge() {
 		return
 	}
 
+	ctx, ok := a.mergeTask.begin()
+	if !ok {
+		dialog.ShowInformation("提示", "合并任务正在进行中", a.window)
+		return
+	}
+
 	go func() {
+		defer a.mergeTask.end()
 		a.mergeStatus.SetText("🔄 正在合并文件...")
-		a.mergeProgress.SetValue(0)
+		resetProgress(a.mergeProgress)
 
 		// 使用 Windows 原生文件保存对话框
 		outputPath, err := nativeDialog.File().
@@ -242,88 +254,34 @@ func (a *App) startMerge() {
 			outputPath += ".txt"
 		}
 
-		err = a.performMerge(outputPath)
-		if err != nil {
+		note, err := a.performMerge(ctx, outputPath)
+		if err == engine.ErrCanceled {
+			a.mergeStatus.SetText("⏹ 已停止：未完成的输出文件已删除，源文件未改动")
+			return
+		} else if err != nil {
 			a.mergeStatus.SetText("❌ 合并失败: " + err.Error())
 			dialog.ShowError(err, a.window)
 		} else {
-			a.mergeStatus.SetText("✅ 合并完成")
+			a.mergeStatus.SetText("✅ 合并完成" + note)
 			dialog.ShowInformation("完成", "文件合并成功！\n输出文件: "+filepath.Base(outputPath), a.window)
 		}
 		a.mergeProgress.SetValue(1.0)
 	}()
 }
 
-// 执行合并操作
-func (a *App) performMerge(outputPath string) error {
-	// 删除可能存在的空文件
-	os.Remove(outputPath)
-
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
-	}
-	defer outputFile.Close()
-
-	writer := bufio.NewWriter(outputFile)
-	defer writer.Flush()
-
-	uniqueLines := make(map[string]bool)
-	totalFiles := len(a.mergeFiles)
-	linesWritten := 0
-
-	for i, filePath := range a.mergeFiles {
-		a.mergeProgress.SetValue(float64(i) / float64(totalFiles))
-		a.mergeStatus.SetText(fmt.Sprintf("🔄 处理文件 %d/%d: %s", i+1, totalFiles, filepath.Base(filePath)))
-
-		file, err := os.Open(filePath)
-		if err != nil {
-			return fmt.Errorf("打开文件 %s 失败: %v", filePath, err)
-		}
-
-		scanner := bufio.NewScanner(file)
-		// 设置更大的缓冲区以处理长行，避免 "token too long" 错误
-		buf := make([]byte, 0, 128*1024) // 128KB初始缓冲区
-		scanner.Buffer(buf, 2*1024*1024) // 2MB最大行长度
-
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
-				continue
-			}
-
-			if a.mergeDedup.Checked {
-				if !uniqueLines[line] {
-					uniqueLines[line] = true
-					_, err := writer.WriteString(line + "\n")
-					if err != nil {
-						file.Close()
-						return fmt.Errorf("写入文件失败: %v", err)
-					}
-					linesWritten++
-				}
-			} else {
-				_, err := writer.WriteString(line + "\n")
-				if err != nil {
-					file.Close()
-					return fmt.Errorf("写入文件失败: %v", err)
-				}
-				linesWritten++
-			}
-		}
-
-		file.Close()
-		if err := scanner.Err(); err != nil {
-			return fmt.Errorf("读取文件 %s 失败: %v", filePath, err)
-		}
-	}
-
-	// 强制刷新缓冲区
-	err = writer.Flush()
+// 执行合并操作，返回状态栏中的校验说明
+func (a *App) performMerge(ctx context.Context, outputPath string) (string, error) {
+	result, err := engine.Merge(ctx, engine.MergeOptions{
+		Inputs:    a.mergeFiles,
+		Output:    outputPath,
+		Dedup:     a.mergeDedup.Checked,
+		Normalize: a.mergeNormalize.options(),
+		Rejects:   a.mergeRejects.Checked,
+	}, widgetReporter{a.mergeProgress, a.mergeStatus})
 	if err != nil {
-		return fmt.Errorf("刷新缓冲区失败: %v", err)
+		return "", err
 	}
 
-	fmt.Printf("✅ 合并完成，共写入 %d 行到文件: %s\n", linesWritten, outputPath)
-	return nil
+	fmt.Printf("✅ 合并完成，共写入 %d 行到文件: %s\n", result.LinesWritten, outputPath)
+	return rejectsNote(a.mergeRejects.Checked, result.Rejected, result.RejectsFile), nil
 }
@@ -1,10 +1,8 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"math/rand"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -14,6 +12,8 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
 )
 
 // 创建号码增加标签页
@@ -57,6 +57,7 @@ func (a *App) createNumberAddTab() *fyne.Container {
 
 	// 选项设置
 	a.numberAddRemoveEmpty = widget.NewCheck("🗑️ 去除空行", nil)
+	a.numberAddRejects = newRejectsCheck()
 
 	// 开始处理按钮
 	processBtn := widget.NewButtonWithIcon("🔢 开始增加", nil, func() {
@@ -69,7 +70,7 @@ func (a *App) createNumberAddTab() *fyne.Container {
 	processBtn.Importance = widget.HighImportance
 
 	// 进度区域
-	a.numberAddProgress = widget.NewProgressBar()
+	a.numberAddProgress = newProgressBar()
 	a.numberAddStatus = widget.NewLabel("📋 就绪")
 	a.numberAddStatus.TextStyle = fyne.TextStyle{Italic: true}
 
@@ -96,11 +97,12 @@ func (a *App) createNumberAddTab() *fyne.Container {
 		widget.NewSeparator(),
 		widget.NewLabel("🔧 处理选项:"),
 		a.numberAddRemoveEmpty,
+		a.numberAddRejects,
 	)
 
 	bottomSection := container.NewVBox(
 		widget.NewSeparator(),
-		container.NewHBox(widget.NewLabel(""), processBtn),
+		container.NewHBox(widget.NewLabel(""), processBtn, a.numberAddTask.newStopButton()),
 		widget.NewSeparator(),
 		widget.NewLabel("📊 进度状态:"),
 		a.numberAddProgress,
@@ -185,24 +187,34 @@ func (a *App) startNumberAdd() {
 	// 获取用户输入的字符（可为空）
 	userDigit := strings.TrimSpace(a.numberAddDigit.Text)
 
+	ctx, ok := a.numberAddTask.begin()
+	if !ok {
+		dialog.ShowInformation("提示", "号码增加任务正在进行中", a.window)
+		return
+	}
+
 	go func() {
+		defer a.numberAddTask.end()
 		a.numberAddStatus.SetText("🔄 正在处理号码增加...")
-		a.numberAddProgress.SetValue(0)
+		resetProgress(a.numberAddProgress)
 
-		err := a.performNumberAdd(position, userDigit)
-		if err != nil {
+		note, err := a.performNumberAdd(ctx, position, userDigit)
+		if err == engine.ErrCanceled {
+			a.numberAddStatus.SetText("⏹ 已停止：未完成的输出文件已删除，源文件未改动")
+			return
+		} else if err != nil {
 			a.numberAddStatus.SetText("❌ 处理失败: " + err.Error())
 			dialog.ShowError(err, a.window)
 		} else {
-			a.numberAddStatus.SetText("✅ 处理完成")
+			a.numberAddStatus.SetText("✅ 处理完成" + note)
 			dialog.ShowInformation("完成", "号码增加处理成功！", a.window)
 		}
 		a.numberAddProgress.SetValue(1.0)
 	}()
 }
 
-// 执行号码增加操作（简化版，无去重功能）
-func (a *App) performNumberAdd(position int, userDigit string) error {
+// 执行号码增加操作（简化版，无去重功能），返回状态栏中的校验说明
+func (a *App) performNumberAdd(ctx context.Context, position int, userDigit string) (string, error) {
 	// 使用 Windows 原生文件保存对话框
 	outputPath, err := nativeDialog.File().
 		Filter("文本文件", "txt").
@@ -210,7 +222,7 @@ func (a *App) performNumberAdd(position int, userDigit string) error {
 		Save()
 
 	if err != nil {
-		return fmt.Errorf("保存对话框取消或失败: %v", err)
+		return "", fmt.Errorf("保存对话框取消或失败: %v", err)
 	}
 
 	// 确保输出文件有.txt扩展名
@@ -218,112 +230,20 @@ func (a *App) performNumberAdd(position int, userDigit string) error {
 		outputPath += ".txt"
 	}
 
-	// 删除已存在的输出文件
-	if _, err := os.Stat(outputPath); err == nil {
-		os.Remove(outputPath)
-	}
-
-	file, err := os.Open(a.numberAddFile)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %v", err)
-	}
-	defer file.Close()
-
-	outputFile, err := os.Create(outputPath)
+	result, err := engine.NumberAdd(ctx, engine.NumberAddOptions{
+		Input:       a.numberAddFile,
+		Output:      outputPath,
+		Position:    position,
+		Digit:       userDigit,
+		RemoveEmpty: a.numberAddRemoveEmpty.Checked,
+		Rejects:     a.numberAddRejects.Checked,
+	}, widgetReporter{a.numberAddProgress, a.numberAddStatus})
 	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
-	}
-	defer outputFile.Close()
-
-	writer := bufio.NewWriter(outputFile)
-	defer writer.Flush()
-
-	scanner := bufio.NewScanner(file)
-	// 设置更大的缓冲区以处理长行，避免 "token too long" 错误
-	buf := make([]byte, 0, 128*1024) // 128KB初始缓冲区
-	scanner.Buffer(buf, 2*1024*1024) // 2MB最大行长度
-
-	totalLines := 0
-	processedLines := 0
-
-	// 逐行读取并处理
-	for scanner.Scan() {
-		line := scanner.Text()
-		totalLines++
-
-		// 去除空行处理（如果勾选了去空选项）
-		if a.numberAddRemoveEmpty.Checked && strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		// 如果行为空且不需要去空行，则直接写入
-		if strings.TrimSpace(line) == "" {
-			_, err := writer.WriteString(line + "\n")
-			if err != nil {
-				return fmt.Errorf("写入文件失败: %v", err)
-			}
-			processedLines++
-			continue
-		}
-
-		// 在指定位置增加字符（用户输入或随机）
-		processedLine := a.addDigitAtPosition(line, position, userDigit)
-
-		// 直接写入处理后的行（无去重）
-		_, err := writer.WriteString(processedLine + "\n")
-		if err != nil {
-			return fmt.Errorf("写入文件失败: %v", err)
-		}
-		processedLines++
-
-		// 更新进度
-		if totalLines%1000 == 0 {
-			progress := float64(totalLines) / 100000.0 // 假设最大10万行
-			if progress > 1.0 {
-				progress = 1.0
-			}
-			a.numberAddProgress.SetValue(progress)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
-	}
-
-	// 强制刷新缓冲区
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("刷新缓冲区失败: %v", err)
+		return "", err
 	}
 
 	fmt.Printf("✅ 号码增加完成: 总行数 %d，处理行数 %d，输出文件: %s\n",
-		totalLines, processedLines, filepath.Base(outputPath))
-
-	return nil
-}
-
-// 在指定位置增加字符（用户输入或随机）
-func (a *App) addDigitAtPosition(line string, position int, userDigit string) string {
-	var charToAdd string
-
-	// 如果用户输入了字符，直接使用用户输入；否则使用随机数字
-	if userDigit != "" {
-		// 直接使用用户输入的任何字符
-		charToAdd = userDigit
-	} else {
-		// 用户未输入，生成随机数字（0-9）
-		charToAdd = strconv.Itoa(rand.Intn(10))
-	}
-
-	// 如果位置为0，在开头添加
-	if position == 0 {
-		return charToAdd + line
-	}
-
-	// 如果位置超过字符串长度，则在末尾添加
-	if position >= len(line) {
-		return line + charToAdd
-	}
+		result.LinesRead, result.LinesWritten, filepath.Base(outputPath))
 
-	// 在指定位置后插入字符
-	return line[:position] + charToAdd + line[position:]
+	return rejectsNote(a.numberAddRejects.Checked, result.Rejected, result.RejectsFile), nil
 }
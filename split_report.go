@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"ts-merge-go/engine"
+)
+
+// 按分组均分的结果：每组（国家、前缀或运营商）在各份中的行数
+func newSplitGroupsView(result *engine.SplitResult) fyne.CanvasObject {
+	header := []string{result.GroupBy, "合计"}
+	for i := range result.PartLines {
+		header = append(header, fmt.Sprintf("第 %d 份", i+1))
+	}
+	rows := [][]string{header}
+	for _, g := range result.Groups {
+		row := []string{g.Name, strconv.Itoa(g.Count)}
+		for _, n := range g.PartLines {
+			row = append(row, strconv.Itoa(n))
+		}
+		rows = append(rows, row)
+	}
+	total := []string{"合计", strconv.Itoa(result.LinesWritten)}
+	for _, n := range result.PartLines {
+		total = append(total, strconv.Itoa(n))
+	}
+	rows = append(rows, total)
+
+	table := widget.NewTable(
+		func() (int, int) { return len(rows), len(header) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			label.SetText(rows[id.Row][id.Col])
+			label.TextStyle = fyne.TextStyle{Bold: id.Row == 0 || id.Row == len(rows)-1}
+			label.Refresh()
+		},
+	)
+	table.SetColumnWidth(0, 180)
+	for col := 1; col < len(header); col++ {
+		table.SetColumnWidth(col, 100)
+	}
+	summary := widget.NewLabel(fmt.Sprintf("共 %d 个%s，每组按比例分到 %d 份", len(result.Groups), result.GroupBy, len(result.PartLines)))
+	return container.NewVBox(
+		widget.NewLabel("📈 各份构成:"),
+		summary,
+		container.NewGridWrap(fyne.NewSize(700, 240), table),
+	)
+}
@@ -1,18 +1,20 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
-	//nativeDialog "github.com/sqweek/dialog"
+	nativeDialog "github.com/sqweek/dialog"
+
+	"ts-merge-go/engine"
 )
 
 // 创建文件拆分标签页
@@ -42,15 +44,71 @@ func (a *App) createSplitTab() *fyne.Container {
 			}
 			a.splitFile = file
 			a.splitFileLabel.SetText(filepath.Base(file))
+			a.loadSplitPreview(file)
 			fmt.Printf("✅ 选择拆分文件: %s\n", filepath.Base(file))
 		}
 	})
 
-	// 拆分参数
+	// 拆分参数：拆分方式决定数值的含义
 	a.splitParts = widget.NewEntry()
-	a.splitParts.SetPlaceHolder("输入拆分份数，如：3")
+	a.splitParts.OnChanged = func(string) { a.updateSplitPreview() }
+	splitValueLabel := widget.NewLabel("")
+	a.splitSeed = widget.NewEntry()
+	a.splitSeed.SetPlaceHolder("留空则随机生成，填写相同的种子可重现同样的分配")
+	a.splitSalt = widget.NewEntry()
+	a.splitSalt.SetPlaceHolder("可留空；份数和盐不变时，同一号码每次都分到同一份")
+	a.splitPrefixDigits = widget.NewEntry()
+	a.splitPrefixDigits.SetText("3")
+	a.splitPrefixDigits.SetPlaceHolder("按号码前缀分组时取前几位，如：3")
+	a.splitStratifyBy = widget.NewSelect(engine.StratifyKeys, func(string) {
+		if a.splitMode.SelectedIndex() == int(engine.SplitStratified) && a.splitStratifyBy.SelectedIndex() == int(engine.StratifyByPrefix) {
+			a.splitPrefixDigits.Enable()
+		} else {
+			a.splitPrefixDigits.Disable()
+		}
+	})
+	a.splitMode = widget.NewSelect(engine.SplitModes, func(string) {
+		mode := engine.SplitMode(a.splitMode.SelectedIndex())
+		if mode == engine.SplitShuffle {
+			a.splitSeed.Enable()
+		} else {
+			a.splitSeed.Disable()
+		}
+		if mode == engine.SplitHash {
+			a.splitSalt.Enable()
+		} else {
+			a.splitSalt.Disable()
+		}
+		if mode == engine.SplitStratified {
+			a.splitStratifyBy.Enable()
+		} else {
+			a.splitStratifyBy.Disable()
+		}
+		a.splitStratifyBy.OnChanged(a.splitStratifyBy.Selected)
+		switch mode {
+		case engine.SplitByLines:
+			splitValueLabel.SetText("每份行数:")
+			a.splitParts.SetPlaceHolder("每个文件最多的行数，如：50000")
+		case engine.SplitBySize:
+			splitValueLabel.SetText("每份大小:")
+			a.splitParts.SetPlaceHolder("每个文件最大的大小，如：5MB、500KB")
+		case engine.SplitWeighted:
+			splitValueLabel.SetText("各份比例:")
+			a.splitParts.SetPlaceHolder("各份的比例，如：50/30/20")
+		default:
+			splitValueLabel.SetText("拆分份数:")
+			a.splitParts.SetPlaceHolder("输入拆分份数，如：3")
+		}
+		a.updateSplitPreview()
+	})
+	a.splitPreview.label = widget.NewLabel("")
+	a.splitStratifyBy.SetSelectedIndex(int(engine.StratifyByCountry))
+	a.splitMode.SetSelectedIndex(int(engine.SplitByParts))
+	a.splitResults = container.NewVBox()
 
 	a.splitDedup = widget.NewCheck("🔄 去除重复行", nil)
+	a.splitOutputDir = widget.NewCheck("📂 输出到其他文件夹（开始拆分时选择），否则写在源文件旁边", nil)
+	a.splitRejects = newRejectsCheck()
 
 	splitBtn := widget.NewButtonWithIcon("✂️ 开始拆分", nil, func() {
 		if a.splitFile == "" {
@@ -62,7 +120,7 @@ func (a *App) createSplitTab() *fyne.Container {
 	splitBtn.Importance = widget.HighImportance
 
 	// 进度区域
-	a.splitProgress = widget.NewProgressBar()
+	a.splitProgress = newProgressBar()
 	a.splitStatus = widget.NewLabel("📋 就绪")
 	a.splitStatus.TextStyle = fyne.TextStyle{Italic: true}
 
@@ -80,19 +138,34 @@ func (a *App) createSplitTab() *fyne.Container {
 		widget.NewSeparator(),
 		widget.NewLabel("⚙️ 拆分设置:"),
 		container.NewGridWithColumns(2,
-			widget.NewLabel("拆分份数:"),
+			widget.NewLabel("拆分方式:"),
+			a.splitMode,
+			splitValueLabel,
 			a.splitParts,
+			widget.NewLabel("随机种子:"),
+			a.splitSeed,
+			widget.NewLabel("哈希盐:"),
+			a.splitSalt,
+			widget.NewLabel("分组依据:"),
+			a.splitStratifyBy,
+			widget.NewLabel("前缀位数:"),
+			a.splitPrefixDigits,
 		),
+		a.splitPreview.label,
 		a.splitDedup,
+		a.splitOutputDir,
+		a.splitRejects,
+		a.splitNormalize.newWidget(a.window),
 	)
 
 	bottomSection := container.NewVBox(
 		widget.NewSeparator(),
-		container.NewHBox(widget.NewLabel(""), splitBtn),
+		container.NewHBox(widget.NewLabel(""), splitBtn, a.splitTask.newStopButton()),
 		widget.NewSeparator(),
 		widget.NewLabel("📊 进度状态:"),
 		a.splitProgress,
 		a.splitStatus,
+		a.splitResults,
 	)
 
 	return container.NewVBox(
@@ -144,6 +217,7 @@ func (a *App) createSplitDropArea() *fyne.Container {
 			}
 			a.splitFile = file
 			a.splitFileLabel.SetText(filepath.Base(file))
+			a.loadSplitPreview(file)
 			fmt.Printf("✅ 选择拆分文件: %s\n", filepath.Base(file))
 		}
 	})
@@ -164,99 +238,171 @@ func (a *App) startSplit() {
 		return
 	}
 
-	parts, err := strconv.Atoi(a.splitParts.Text)
-	if err != nil || parts <= 0 {
-		dialog.ShowError(fmt.Errorf("请输入有效的拆分份数"), a.window)
+	opts, err := a.splitOptions()
+	if err != nil {
+		dialog.ShowError(err, a.window)
+		return
+	}
+
+	ctx, ok := a.splitTask.begin()
+	if !ok {
+		dialog.ShowInformation("提示", "拆分任务正在进行中", a.window)
 		return
 	}
 
 	go func() {
+		defer a.splitTask.end()
 		a.splitStatus.SetText("🔄 正在拆分文件...")
-		a.splitProgress.SetValue(0)
+		resetProgress(a.splitProgress)
+		a.splitResults.RemoveAll()
 
-		err := a.performSplit(parts)
-		if err != nil {
+		if a.splitOutputDir.Checked {
+			outputDir, err := nativeDialog.Directory().Title("选择分片文件的输出文件夹").Browse()
+			if err != nil {
+				a.splitStatus.SetText("❌ 拆分已取消")
+				return
+			}
+			opts.OutputDir = outputDir
+		}
+
+		result, err := a.performSplit(ctx, opts)
+		if err == engine.ErrCanceled {
+			a.splitStatus.SetText("⏹ 已停止：本次生成的分片文件已删除，源文件未改动")
+			return
+		} else if err != nil {
 			a.splitStatus.SetText("❌ 拆分失败: " + err.Error())
 			dialog.ShowError(err, a.window)
 		} else {
-			a.splitStatus.SetText("✅ 拆分完成")
-			dialog.ShowInformation("完成", fmt.Sprintf("文件拆分成功！\n已拆分为 %d 个文件", parts), a.window)
+			status := "✅ 拆分完成"
+			if opts.Mode == engine.SplitShuffle {
+				status += fmt.Sprintf("（随机种子 %d）", result.Seed)
+			}
+			a.splitStatus.SetText(status + rejectsNote(opts.Rejects, result.Rejected, result.RejectsFile))
+			dialog.ShowInformation("完成", fmt.Sprintf("文件拆分成功！\n已拆分为 %d 个文件\n各份行数: %s", len(result.Outputs), joinInts(result.PartLines, " / ")), a.window)
 		}
 		a.splitProgress.SetValue(1.0)
 	}()
 }
 
-// 执行拆分操作
-func (a *App) performSplit(parts int) error {
-	file, err := os.Open(a.splitFile)
+// 按界面上的拆分方式和数值生成拆分参数（不含文件和选项）
+func (a *App) splitOptions() (engine.SplitOptions, error) {
+	opts := engine.SplitOptions{Mode: engine.SplitMode(a.splitMode.SelectedIndex())}
+	text := strings.TrimSpace(a.splitParts.Text)
+	var err error
+	switch opts.Mode {
+	case engine.SplitByLines:
+		opts.LinesPerPart, err = strconv.Atoi(text)
+	case engine.SplitBySize:
+		opts.MaxBytes, err = engine.ParseByteSize(text)
+	case engine.SplitWeighted:
+		if opts.Weights, err = engine.ParseWeights(text); err != nil {
+			return opts, err
+		}
+	default:
+		opts.Parts, err = strconv.Atoi(text)
+	}
 	if err != nil {
-		return fmt.Errorf("打开文件失败: %v", err)
+		opts.Parts, opts.LinesPerPart, opts.MaxBytes = 0, 0, 0
+	}
+	if opts.Mode == engine.SplitHash {
+		opts.Salt = a.splitSalt.Text
 	}
-	defer file.Close()
-
-	// 读取所有行
-	var lines []string
-	uniqueLines := make(map[string]bool)
-	scanner := bufio.NewScanner(file)
-	// 设置更大的缓冲区以处理长行，避免 "token too long" 错误
-	buf := make([]byte, 0, 128*1024) // 128KB初始缓冲区
-	scanner.Buffer(buf, 2*1024*1024) // 2MB最大行长度
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+	if opts.Mode == engine.SplitStratified {
+		opts.StratifyBy = engine.StratifyKey(a.splitStratifyBy.SelectedIndex())
+		if opts.StratifyBy == engine.StratifyByPrefix {
+			opts.PrefixDigits, _ = strconv.Atoi(strings.TrimSpace(a.splitPrefixDigits.Text))
 		}
-
-		if a.splitDedup.Checked {
-			if !uniqueLines[line] {
-				uniqueLines[line] = true
-				lines = append(lines, line)
-			}
-		} else {
-			lines = append(lines, line)
+	}
+	if seed := strings.TrimSpace(a.splitSeed.Text); opts.Mode == engine.SplitShuffle && seed != "" {
+		if opts.Seed, err = strconv.ParseInt(seed, 10, 64); err != nil || opts.Seed == 0 {
+			return opts, fmt.Errorf("随机种子必须是非零整数")
 		}
 	}
+	return opts, opts.Validate()
+}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
+// 执行拆分操作
+func (a *App) performSplit(ctx context.Context, opts engine.SplitOptions) (*engine.SplitResult, error) {
+	opts.Input = a.splitFile
+	opts.Dedup = a.splitDedup.Checked
+	opts.Normalize = a.splitNormalize.options()
+	opts.Rejects = a.splitRejects.Checked
+	result, err := engine.Split(ctx, opts, widgetReporter{a.splitProgress, a.splitStatus})
+	if err != nil {
+		return nil, err
 	}
-
-	totalLines := len(lines)
-	if totalLines == 0 {
-		return fmt.Errorf("文件为空或没有有效内容")
+	if len(result.Groups) > 0 {
+		a.splitResults.Add(newSplitGroupsView(result))
 	}
 
-	linesPerPart := totalLines / parts
-	remainder := totalLines % parts
+	fmt.Printf("✅ 拆分完成: 共 %d 行，拆分为 %d 个文件\n", result.LinesWritten, len(result.Outputs))
+	return result, nil
+}
 
-	baseFileName := strings.TrimSuffix(a.splitFile, filepath.Ext(a.splitFile))
+// 拆分前预览：选择文件后在后台统计行数和大小，修改拆分方式或数值时即时更新预计份数
+type splitPreview struct {
+	mu    sync.Mutex
+	file  string            // 正在统计或已统计的文件
+	stats *engine.FileStats // 统计完成前为空
+	label *widget.Label
+}
 
-	for i := 0; i < parts; i++ {
-		a.splitProgress.SetValue(float64(i) / float64(parts))
+// 选择拆分文件后在后台统计行数和大小
+func (a *App) loadSplitPreview(file string) {
+	p := &a.splitPreview
+	p.mu.Lock()
+	p.file, p.stats = file, nil
+	p.mu.Unlock()
+	p.label.SetText("🔄 正在统计文件行数...")
 
-		start := i * linesPerPart
-		end := start + linesPerPart
-		if i < remainder {
-			end++
-		}
-		if i == parts-1 {
-			end = totalLines
+	go func() {
+		stats, err := engine.ScanFileStats(context.Background(), file)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.file != file { // 统计期间又选择了其他文件
+			return
 		}
-
-		outputPath := fmt.Sprintf("%s_part%d.txt", baseFileName, i+1)
-		outputFile, err := os.Create(outputPath)
 		if err != nil {
-			return fmt.Errorf("创建输出文件失败: %v", err)
+			p.label.SetText("❌ 统计文件失败: " + err.Error())
+			return
 		}
+		p.stats = &stats
+		a.updateSplitPreviewLocked()
+	}()
+}
 
-		writer := bufio.NewWriter(outputFile)
-		for j := start; j < end && j < len(lines); j++ {
-			writer.WriteString(lines[j] + "\n")
+// 按当前拆分方式和数值更新预计份数
+func (a *App) updateSplitPreview() {
+	a.splitPreview.mu.Lock()
+	defer a.splitPreview.mu.Unlock()
+	a.updateSplitPreviewLocked()
+}
+
+func (a *App) updateSplitPreviewLocked() {
+	p := &a.splitPreview
+	if p.stats == nil {
+		if p.file == "" {
+			p.label.SetText("📦 选择文件后显示预计生成的文件数")
 		}
-		writer.Flush()
-		outputFile.Close()
+		return
 	}
+	opts, err := a.splitOptions()
+	if err != nil {
+		p.label.SetText(fmt.Sprintf("📦 共 %d 行，%s；%v", p.stats.Lines, engine.FormatByteSize(p.stats.Bytes), err))
+		return
+	}
+	text := fmt.Sprintf("📦 预计生成 %d 个文件（共 %d 行，%s", opts.EstimateParts(*p.stats), p.stats.Lines, engine.FormatByteSize(p.stats.Bytes))
+	if opts.Mode == engine.SplitBySize {
+		text += "，按行切分可能多一份"
+	}
+	p.label.SetText(text + "；去重或校验丢弃的行未计入）")
+}
 
-	return nil
+// 用分隔符连接各份的行数，如 500 / 300 / 200
+func joinInts(values []int, sep string) string {
+	texts := make([]string, len(values))
+	for i, v := range values {
+		texts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(texts, sep)
 }
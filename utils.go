@@ -9,14 +9,21 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
 
 	nativeDialog "github.com/sqweek/dialog"
 	"github.com/tencentyun/cos-go-sdk-v5"
+
+	"ts-merge-go/engine"
 )
 
 // 验证文件是否包含手机号格式的内容
@@ -104,6 +111,7 @@ func (a *App) handleFileDrop(uris []fyne.URI) {
 			a.splitFile = path
 			if a.splitFileLabel != nil {
 				a.splitFileLabel.SetText(filepath.Base(path))
+				a.loadSplitPreview(path)
 			}
 			fmt.Printf("✅ 拖拽设置拆分文件: %s\n", filepath.Base(path))
 			break // 拆分只需要一个文件
@@ -258,3 +266,192 @@ func (a *App) selectFileAndUpload(desc string, ext string, title string) (string
 
 	return file, err
 }
+
+// 显示速度和剩余时间的进度条。TextFormatter 只在创建时设置一次，
+// 工作协程只更新 text，不会在界面绘制进度条时替换格式化函数
+type progressBar struct {
+	widget.ProgressBar
+	text atomic.Value // string，为空时显示百分比
+}
+
+func newProgressBar() *progressBar {
+	p := &progressBar{}
+	p.Max = 1
+	p.text.Store("")
+	p.TextFormatter = func() string {
+		if text := p.text.Load().(string); text != "" {
+			return text
+		}
+		return strconv.Itoa(int(p.Value*100)) + "%"
+	}
+	p.ExtendBaseWidget(p)
+	return p
+}
+
+// 更新进度和显示的文字，text 为空时显示百分比
+func (p *progressBar) update(value float64, text string) {
+	p.text.Store(text)
+	p.SetValue(value)
+}
+
+// 将引擎的进度事件转发到界面控件
+type widgetReporter struct {
+	progress *progressBar
+	status   *widget.Label
+}
+
+func (w widgetReporter) Progress(stats engine.Stats) {
+	w.progress.update(stats.Fraction, stats.String())
+}
+
+func (w widgetReporter) Status(text string) {
+	w.status.SetText(text)
+}
+
+// 清空进度条，恢复默认的百分比显示
+func resetProgress(progress *progressBar) {
+	progress.update(0, "")
+}
+
+// 标签页后台任务的取消控制，配合停止按钮使用
+type taskControl struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	stopBtn *widget.Button
+}
+
+// 创建停止按钮，任务运行期间可用
+func (t *taskControl) newStopButton() *widget.Button {
+	t.stopBtn = widget.NewButtonWithIcon("⏹ 停止", nil, t.stop)
+	t.stopBtn.Importance = widget.DangerImportance
+	t.stopBtn.Disable()
+	return t.stopBtn
+}
+
+// 开始新任务，已有任务在运行时返回 false
+func (t *taskControl) begin() (context.Context, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel != nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.stopBtn.Enable()
+	return ctx, true
+}
+
+// 任务结束（完成、失败或已取消）后调用
+func (t *taskControl) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	t.stopBtn.Disable()
+}
+
+// 请求停止当前任务
+func (t *taskControl) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
+// 标签页的号码规范化选项：勾选后按规则规范化，规则可在“规则...”对话框中调整
+type normalizeControl struct {
+	check *widget.Check
+	rules engine.NormalizeOptions
+}
+
+// 创建规范化勾选框和规则按钮
+func (n *normalizeControl) newWidget(win fyne.Window) fyne.CanvasObject {
+	n.rules = engine.DefaultNormalizeOptions()
+	n.check = widget.NewCheck("🧹 号码规范化", nil)
+	rulesBtn := widget.NewButton("规则...", func() {
+		n.showRulesDialog(win)
+	})
+	return container.NewHBox(n.check, rulesBtn)
+}
+
+// 当前生效的规范化规则，未勾选时返回零值（不做任何处理）
+func (n *normalizeControl) options() engine.NormalizeOptions {
+	if n.check == nil || !n.check.Checked {
+		return engine.NormalizeOptions{}
+	}
+	return n.rules
+}
+
+func (n *normalizeControl) showRulesDialog(win fyne.Window) {
+	separators := widget.NewCheck("去除空格、横线、点和括号", nil)
+	separators.SetChecked(n.rules.StripSeparators)
+	prefix := widget.NewCheck("去除 + 和 00 国际前缀", nil)
+	prefix.SetChecked(n.rules.InternationalPrefix)
+	fullWidth := widget.NewCheck("全角数字转为半角", nil)
+	fullWidth.SetChecked(n.rules.FullWidth)
+	invisible := widget.NewCheck("去除零宽字符等不可见字符", nil)
+	invisible.SetChecked(n.rules.StripInvisible)
+	format := widget.NewRadioGroup(engine.OutputFormats, nil)
+	format.SetSelected(engine.OutputFormats[n.rules.Format])
+
+	content := container.NewVBox(
+		separators,
+		prefix,
+		fullWidth,
+		invisible,
+		widget.NewSeparator(),
+		widget.NewLabel("输出格式（去重时写入第一次出现的号码）:"),
+		format,
+	)
+	dialog.ShowCustomConfirm("号码规范化规则", "确定", "取消", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		n.rules = engine.NormalizeOptions{
+			StripSeparators:     separators.Checked,
+			InternationalPrefix: prefix.Checked,
+			FullWidth:           fullWidth.Checked,
+			StripInvisible:      invisible.Checked,
+		}
+		for i, name := range engine.OutputFormats {
+			if name == format.Selected {
+				n.rules.Format = engine.OutputFormat(i)
+			}
+		}
+		n.check.SetChecked(true)
+	}, win)
+}
+
+// 号码类型处理方式选择框，默认不区分类型
+func newTypeModeSelect() *widget.Select {
+	sel := widget.NewSelect(engine.TypeModes, nil)
+	sel.SetSelectedIndex(int(engine.TypeAll))
+	return sel
+}
+
+// 选择框当前的号码类型处理方式
+func selectedTypeMode(sel *widget.Select) engine.TypeMode {
+	if i := sel.SelectedIndex(); i >= 0 {
+		return engine.TypeMode(i)
+	}
+	return engine.TypeAll
+}
+
+// 号码校验勾选框：按各国号码长度校验每一行，不合格的行写入 _rejects.txt
+func newRejectsCheck() *widget.Check {
+	return widget.NewCheck("🧪 校验号码长度（不合格的行不输出，写入 _rejects.txt 并注明行号和原因）", nil)
+}
+
+// 状态栏中不合格行数的说明，没有开启校验时为空
+func rejectsNote(enabled bool, rejected int, rejectsFile string) string {
+	if !enabled {
+		return ""
+	}
+	if rejected == 0 {
+		return "，校验全部合格"
+	}
+	return fmt.Sprintf("，校验不合格 %d 行（见 %s）", rejected, filepath.Base(rejectsFile))
+}